@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -22,8 +24,15 @@ func main() {
 		log.Printf("Warning: Error loading .env file: %v", err)
 	}
 
-	// Initialize database configuration
+	// Initialize configuration
 	dbConfig := config.NewDatabaseConfig()
+	llmConfig := config.NewLLMConfig()
+	if err := llmConfig.Validate(); err != nil {
+		log.Fatalf("Invalid LLM configuration: %v", err)
+	}
+	jobConfig := config.NewJobConfig()
+	keywordConfig := config.NewKeywordConfig()
+	embeddingConfig := config.NewEmbeddingConfig()
 
 	// Create database connection
 	db, err := database.NewDB(dbConfig.ConnectionString())
@@ -34,23 +43,61 @@ func main() {
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
+	resumeRepo := repository.NewResumeRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	refinementRepo := repository.NewRefinementRepository(db)
+	keywordRepo := repository.NewKeywordRepository(db)
+	skillRepo := repository.NewSkillRepository(db)
+
+	if keywordConfig.StopwordsFile != "" {
+		if err := service.AddStopwordsFile(keywordConfig.StopwordsFile); err != nil {
+			log.Fatalf("Failed to load stopwords: %v", err)
+		}
+	}
 
 	// Initialize services
 	userService := service.NewUserService(userRepo, db)
-	keywordService := service.NewKeywordService(db)
-	llmService := service.NewLLMService()
-	resumeService := service.NewResumeService(db, keywordService, llmService, userService)
+	embedder, err := service.NewEmbedder(embeddingConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize embedder: %v", err)
+	}
+	keywordService := service.NewKeywordService(db, keywordRepo, skillRepo, keywordConfig, embedder)
+	if err := keywordService.CheckEmbeddingDimensions(context.Background()); err != nil {
+		log.Fatalf("Embedding configuration does not match the database: %v", err)
+	}
+	if err := keywordService.SeedSkills(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	llmProvider, err := service.NewLLMProvider(llmConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize LLM provider: %v", err)
+	}
+	resumeService := service.NewResumeService(db, resumeRepo, keywordService, llmProvider, userService, llmConfig)
+	modelService := service.NewModelService(llmProvider, llmConfig)
+	refinementService := service.NewRefinementService(refinementRepo, resumeRepo, resumeService, llmProvider)
+	jobService := service.NewJobService(jobRepo, resumeService, jobConfig)
+
+	// Start background generation workers
+	jobService.Start()
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService)
-	resumeHandler := handlers.NewResumeHandler(resumeService)
+	resumeHandler := handlers.NewResumeHandler(resumeService, jobService, modelService)
+	jobHandler := handlers.NewJobHandler(jobService)
+	modelHandler := handlers.NewModelHandler(modelService)
+	refinementHandler := handlers.NewRefinementHandler(refinementService, resumeService, modelService)
+	keywordHandler := handlers.NewKeywordHandler(keywordService)
 
 	// Setup router
-	r := router.SetupRouter(userHandler, resumeHandler)
+	r := router.SetupRouter(userHandler, resumeHandler, jobHandler, modelHandler, refinementHandler, keywordHandler)
 
 	// Start server
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: r,
+	}
 	go func() {
-		if err := r.Run(":8080"); err != nil {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Failed to start server: %v", err)
 		}
 	}()
@@ -63,8 +110,16 @@ func main() {
 	log.Println("Shutting down server...")
 
 	// Create shutdown context with timeout
-	_, cancel := context.WithTimeout(context.Background(), 5)
+	ctx, cancel := context.WithTimeout(context.Background(), jobConfig.ShutdownTimeout)
 	defer cancel()
 
+	// Requeue running generations first so that another instance can pick them up
+	if err := jobService.Shutdown(ctx); err != nil {
+		log.Printf("Failed to stop generation workers: %v", err)
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
 	log.Println("Server exiting")
 }
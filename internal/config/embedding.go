@@ -0,0 +1,32 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// Supported embedding providers
+const (
+	EmbeddingProviderHashing = "hashing" // In-process feature hashing of keywords, no model needed
+	EmbeddingProviderOllama  = "ollama"  // Ollama's /api/embeddings endpoint
+)
+
+// EmbeddingConfig holds the configuration of the embeddings stored in keyword_vectors
+type EmbeddingConfig struct {
+	Provider   string
+	BaseURL    string // Base URL of the Ollama API
+	Model      string // Embedding model of the Ollama provider
+	Dimensions int    // Length of the vectors; must match the vector_embedding column
+	Timeout    time.Duration
+}
+
+// NewEmbeddingConfig creates a new embedding configuration from environment variables
+func NewEmbeddingConfig() *EmbeddingConfig {
+	return &EmbeddingConfig{
+		Provider:   strings.ToLower(getEnvOrDefault("EMBEDDING_PROVIDER", EmbeddingProviderHashing)),
+		BaseURL:    strings.TrimSuffix(getEnvOrDefault("EMBEDDING_BASE_URL", "http://localhost:11434/api"), "/"),
+		Model:      getEnvOrDefault("EMBEDDING_MODEL", "nomic-embed-text"),
+		Dimensions: getIntOrDefault("EMBEDDING_DIMENSIONS", 50),
+		Timeout:    getDurationOrDefault("EMBEDDING_TIMEOUT", 30*time.Second),
+	}
+}
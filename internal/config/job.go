@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// JobConfig holds the configuration of the background generation workers
+type JobConfig struct {
+	Workers         int           // Number of generations running concurrently in this process
+	QueueSize       int           // Maximum number of queued jobs before new ones are rejected
+	UserQueueSize   int           // Maximum number of queued jobs of a single user
+	MaxAttempts     int           // Attempts per job before it is marked failed
+	RetryBackoff    time.Duration // Delay before a failed attempt is retried, multiplied by the attempt number
+	PollInterval    time.Duration // How often idle workers look for queued jobs
+	StaleAfter      time.Duration // Running jobs whose worker stopped responding for this long are requeued
+	Retention       time.Duration // How long idle event streams of finished or unfollowed jobs are kept in memory
+	ShutdownTimeout time.Duration // How long shutdown waits for running jobs to be requeued
+}
+
+// NewJobConfig creates a new job configuration from environment variables
+func NewJobConfig() *JobConfig {
+	return &JobConfig{
+		Workers:         getIntOrDefault("JOB_WORKERS", 2),
+		QueueSize:       getIntOrDefault("JOB_QUEUE_SIZE", 100),
+		UserQueueSize:   getIntOrDefault("JOB_USER_QUEUE_SIZE", 10),
+		MaxAttempts:     getIntOrDefault("JOB_MAX_ATTEMPTS", 3),
+		RetryBackoff:    getDurationOrDefault("JOB_RETRY_BACKOFF", 10*time.Second),
+		PollInterval:    getDurationOrDefault("JOB_POLL_INTERVAL", 2*time.Second),
+		StaleAfter:      getDurationOrDefault("JOB_STALE_AFTER", 2*time.Minute),
+		Retention:       getDurationOrDefault("JOB_RETENTION", time.Hour),
+		ShutdownTimeout: getDurationOrDefault("JOB_SHUTDOWN_TIMEOUT", 10*time.Second),
+	}
+}
+
+// getIntOrDefault parses a positive integer from the environment, or returns the default
+func getIntOrDefault(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
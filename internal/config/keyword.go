@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Keyword scoring schemes
+const (
+	KeywordScoringTF    = "tf"    // Term frequency within the document only
+	KeywordScoringTFIDF = "tfidf" // Term frequency weighted by inverse document frequency in the corpus
+	KeywordScoringBM25  = "bm25"  // Okapi BM25 weights against the corpus
+)
+
+// KeywordConfig holds the configuration of keyword ranking
+type KeywordConfig struct {
+	Scoring            string // KeywordScoringTF, KeywordScoringTFIDF or KeywordScoringBM25
+	MinCorpusDocuments int    // Corpus size below which ranking falls back to term frequency
+	StopwordsFile      string // File of additional stopwords for this deployment; none when empty
+}
+
+// NewKeywordConfig creates a new keyword configuration from environment variables
+func NewKeywordConfig() *KeywordConfig {
+	return &KeywordConfig{
+		Scoring:            strings.ToLower(getEnvOrDefault("KEYWORD_SCORING", KeywordScoringBM25)),
+		MinCorpusDocuments: getIntOrDefault("KEYWORD_MIN_CORPUS_DOCUMENTS", 20),
+		StopwordsFile:      os.Getenv("KEYWORD_STOPWORDS_FILE"),
+	}
+}
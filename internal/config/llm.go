@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Supported LLM providers
+const (
+	LLMProviderOllama = "ollama" // Ollama's native /api endpoints
+	LLMProviderOpenAI = "openai" // OpenAI-compatible /v1/chat/completions endpoints (OpenAI, vLLM, ...)
+	LLMProviderFake   = "fake"   // In-process deterministic output for development without a model
+)
+
+// Modes of recording LLM traffic to fixture files
+const (
+	LLMFixtureRecord = "record" // Forward requests to the provider and save every exchange
+	LLMFixtureReplay = "replay" // Serve saved exchanges without contacting the provider
+)
+
+// Modes of checking generated resumes against the user's profile
+const (
+	VerifyModeFlag  = "flag"  // Report content that is not in the profile
+	VerifyModeStrip = "strip" // Remove such content and correct personal details
+	VerifyModeOff   = "off"   // Skip the check
+)
+
+// LLMConfig holds the configuration of the LLM backend
+type LLMConfig struct {
+	Provider string
+	BaseURL  string
+	APIKey   string
+	Timeout  time.Duration // Timeout of a whole LLM call, including streaming the output
+
+	Model         string   // Model used for generations unless a request picks another
+	AllowedModels []string // Models requests may pick; any model when empty
+
+	MaxConcurrency int // Maximum number of LLM calls running at once
+	MaxQueueDepth  int // Maximum number of LLM calls waiting for a slot before new ones are rejected
+
+	VerifyMode string // VerifyModeFlag, VerifyModeStrip or VerifyModeOff, unless a request picks another
+
+	FakeLatency time.Duration // Delay between chunks of the fake provider
+
+	FixtureMode string // LLMFixtureRecord, LLMFixtureReplay or empty to talk to the provider directly
+	FixtureDir  string // Directory of recorded fixtures
+}
+
+// NewLLMConfig creates a new LLM configuration from environment variables
+func NewLLMConfig() *LLMConfig {
+	provider := strings.ToLower(getEnvOrDefault("LLM_PROVIDER", LLMProviderOllama))
+
+	return &LLMConfig{
+		Provider: provider,
+		BaseURL:  strings.TrimSuffix(getEnvOrDefault("LLM_BASE_URL", defaultLLMBaseURL(provider)), "/"),
+		APIKey:   os.Getenv("LLM_API_KEY"),
+		Timeout:  getDurationOrDefault("LLM_TIMEOUT", 120*time.Second),
+
+		Model:         getEnvOrDefault("LLM_MODEL", "cusmodel1.2"),
+		AllowedModels: getListOrDefault("LLM_ALLOWED_MODELS", nil),
+
+		MaxConcurrency: getIntOrDefault("LLM_MAX_CONCURRENCY", 1),
+		MaxQueueDepth:  getIntOrDefault("LLM_MAX_QUEUE_DEPTH", 20),
+
+		VerifyMode: strings.ToLower(getEnvOrDefault("RESUME_VERIFY_MODE", VerifyModeFlag)),
+
+		FakeLatency: getDelayOrDefault("LLM_FAKE_LATENCY", 50*time.Millisecond),
+
+		FixtureMode: strings.ToLower(os.Getenv("LLM_FIXTURE_MODE")),
+		FixtureDir:  getEnvOrDefault("LLM_FIXTURE_DIR", "testdata/llm"),
+	}
+}
+
+// Validate reports settings of the configuration that cannot work
+func (c *LLMConfig) Validate() error {
+	switch c.VerifyMode {
+	case VerifyModeFlag, VerifyModeStrip, VerifyModeOff:
+	default:
+		return fmt.Errorf("unsupported resume verify mode %q", c.VerifyMode)
+	}
+	return nil
+}
+
+// defaultLLMBaseURL returns the conventional local address of a provider
+func defaultLLMBaseURL(provider string) string {
+	if provider == LLMProviderOpenAI {
+		return "http://localhost:8000/v1" // Default vLLM OpenAI-compatible server
+	}
+	return "http://localhost:11434/api" // Local Deepseek/Ollama instance
+}
+
+// getListOrDefault parses a comma separated list from the environment, or returns the default
+func getListOrDefault(key string, defaultValue []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+// getDurationOrDefault parses a positive duration such as "250ms" from the environment, or
+// returns the default
+func getDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return defaultValue
+}
+
+// getDelayOrDefault is getDurationOrDefault for delays, which may also be 0
+func getDelayOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d >= 0 {
+		return d
+	}
+	return defaultValue
+}
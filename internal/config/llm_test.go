@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetDurationOrDefault(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", time.Minute},
+		{"250ms", 250 * time.Millisecond},
+		{"0", time.Minute},
+		{"-5s", time.Minute},
+		{"soon", time.Minute},
+	}
+	for _, tt := range tests {
+		t.Setenv("TEST_DURATION", tt.value)
+		if got := getDurationOrDefault("TEST_DURATION", time.Minute); got != tt.want {
+			t.Errorf("getDurationOrDefault(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	t.Setenv("TEST_DURATION", "0")
+	if got := getDelayOrDefault("TEST_DURATION", time.Minute); got != 0 {
+		t.Errorf("getDelayOrDefault(\"0\") = %v, want 0", got)
+	}
+}
+
+func TestLLMConfigValidate(t *testing.T) {
+	for _, tt := range []struct {
+		mode  string
+		valid bool
+	}{
+		{"", true},
+		{VerifyModeFlag, true},
+		{VerifyModeStrip, true},
+		{VerifyModeOff, true},
+		{"Strip", true},
+		{"remove", false},
+	} {
+		t.Setenv("RESUME_VERIFY_MODE", tt.mode)
+		cfg := NewLLMConfig()
+		if err := cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("Validate() with RESUME_VERIFY_MODE=%q returned %v", tt.mode, err)
+		}
+	}
+}
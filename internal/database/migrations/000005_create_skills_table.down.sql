@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_skills;
+DROP TABLE IF EXISTS skills;
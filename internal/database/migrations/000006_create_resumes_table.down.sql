@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS resume_skills;
+DROP TABLE IF EXISTS resumes;
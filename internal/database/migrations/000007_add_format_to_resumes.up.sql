@@ -0,0 +1 @@
+ALTER TABLE resumes ADD COLUMN IF NOT EXISTS format VARCHAR(20) NOT NULL DEFAULT 'markdown';
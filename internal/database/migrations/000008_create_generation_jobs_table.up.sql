@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS generation_jobs (
+    id VARCHAR(32) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    params JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued', -- queued, running, succeeded, failed, canceled
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 3,
+    run_after TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resume_id INTEGER REFERENCES resumes(id) ON DELETE SET NULL,
+    result TEXT,
+    error TEXT,
+    locked_by VARCHAR(100),
+    locked_at TIMESTAMP WITH TIME ZONE, -- Refreshed by the worker while the job runs
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_generation_jobs_user_id ON generation_jobs(user_id);
+-- Workers claim the oldest runnable queued job
+CREATE INDEX idx_generation_jobs_queued ON generation_jobs(run_after, created_at) WHERE status = 'queued';
+CREATE INDEX idx_generation_jobs_running ON generation_jobs(locked_at) WHERE status = 'running';
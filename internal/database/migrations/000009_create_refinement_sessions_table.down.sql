@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS refinement_turns;
+DROP TABLE IF EXISTS refinement_sessions;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS refinement_sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    resume_id INTEGER NOT NULL REFERENCES resumes(id) ON DELETE CASCADE,
+    base_content TEXT NOT NULL, -- Resume content when the session started
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refinement_sessions_user_id ON refinement_sessions(user_id);
+CREATE INDEX idx_refinement_sessions_resume_id ON refinement_sessions(resume_id);
+
+CREATE TABLE IF NOT EXISTS refinement_turns (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES refinement_sessions(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL, -- 1 for the first turn; turn 0 is the base content
+    instruction TEXT NOT NULL,
+    content TEXT NOT NULL, -- Resume content after the turn
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(session_id, number)
+);
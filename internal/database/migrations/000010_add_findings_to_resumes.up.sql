@@ -0,0 +1,2 @@
+-- Verification findings of generated resumes: content not backed by the user's profile
+ALTER TABLE resumes ADD COLUMN IF NOT EXISTS findings JSONB;
@@ -0,0 +1,2 @@
+-- Profile records each generated bullet point is based on
+ALTER TABLE resumes ADD COLUMN IF NOT EXISTS provenance JSONB;
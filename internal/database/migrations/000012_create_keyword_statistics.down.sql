@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS keyword_corpus_stats;
+DROP TABLE IF EXISTS keyword_document_frequencies;
@@ -0,0 +1,15 @@
+-- Number of ingested job descriptions each term occurs in
+CREATE TABLE IF NOT EXISTS keyword_document_frequencies (
+    term TEXT PRIMARY KEY,
+    document_count INTEGER NOT NULL DEFAULT 0
+);
+
+-- Totals of the corpus of ingested job descriptions; a single row
+CREATE TABLE IF NOT EXISTS keyword_corpus_stats (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    document_count INTEGER NOT NULL DEFAULT 0,
+    term_count BIGINT NOT NULL DEFAULT 0, -- Terms of all documents, for the average document length
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO keyword_corpus_stats (id) VALUES (1) ON CONFLICT DO NOTHING;
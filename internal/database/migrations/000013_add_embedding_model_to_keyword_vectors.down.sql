@@ -0,0 +1 @@
+ALTER TABLE keyword_vectors DROP COLUMN IF EXISTS embedding_model;
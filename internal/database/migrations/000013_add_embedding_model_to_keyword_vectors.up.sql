@@ -0,0 +1,2 @@
+-- Embedder that produced vector_embedding; vectors of different embedders are not comparable
+ALTER TABLE keyword_vectors ADD COLUMN IF NOT EXISTS embedding_model VARCHAR(200);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_generation_jobs_user_started;
+ALTER TABLE generation_jobs DROP COLUMN IF EXISTS started_at;
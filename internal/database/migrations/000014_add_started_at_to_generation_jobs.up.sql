@@ -0,0 +1,4 @@
+-- Time the job was last claimed; workers serve the user whose jobs started least recently first
+ALTER TABLE generation_jobs ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_generation_jobs_user_started ON generation_jobs(user_id, started_at);
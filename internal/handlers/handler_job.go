@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repository"
+	"github.com/nikolai/ai-resume-builder/backend/internal/service"
+)
+
+// jobRefreshInterval is how often an idle stream checks whether its job finished in another process
+const jobRefreshInterval = 5 * time.Second
+
+// JobHandler handles requests about background generation jobs
+type JobHandler struct {
+	jobService *service.JobService
+}
+
+// NewJobHandler creates a new JobHandler instance
+func NewJobHandler(jobService *service.JobService) *JobHandler {
+	return &JobHandler{jobService: jobService}
+}
+
+// GetJob returns the status of a job and, once finished, its result
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.jobService.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// StreamJob streams the events of a job. Clients reconnect with the Last-Event-ID header
+// (or the lastEventId query parameter) to resume after the last event they received.
+func (h *JobHandler) StreamJob(c *gin.Context) {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+
+	streamJob(c, h.jobService, c.Param("id"), lastEventID)
+}
+
+// CancelJob stops a queued or running job
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	if err := h.jobService.Cancel(c.Request.Context(), c.Param("id")); err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// streamJob writes the buffered events of a job after lastEventID, then follows new events
+// until the job finishes or the client disconnects. The job keeps running on disconnect.
+func streamJob(c *gin.Context, jobService *service.JobService, id, lastEventID string) {
+	ctx := c.Request.Context()
+	job, err := jobService.Stream(ctx, id)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+	defer job.Unfollow()
+
+	stream, err := newStreamWriter(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	refresh := time.NewTicker(jobRefreshInterval)
+	defer refresh.Stop()
+
+	for {
+		events, changed, finished := job.EventsSince(lastEventID)
+		for _, event := range events {
+			if err := stream.Write(StreamEvent{ID: event.ID, Type: event.Type, Data: event.Data}); err != nil {
+				return
+			}
+			lastEventID = event.ID
+		}
+		if finished {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-refresh.C:
+			// The job may be running in another process, which sends no events here
+			if err := jobService.Refresh(ctx, id, job); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// respondJobError maps job service errors to HTTP responses
+func respondJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+	case errors.Is(err, service.ErrJobQueueFull), errors.Is(err, service.ErrJobUserQueueFull):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrJobRunningElsewhere):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
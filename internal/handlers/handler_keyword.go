@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikolai/ai-resume-builder/backend/internal/service"
+)
+
+// KeywordHandler handles keyword analysis requests
+type KeywordHandler struct {
+	keywordService *service.KeywordService
+}
+
+// NewKeywordHandler creates a new KeywordHandler instance
+func NewKeywordHandler(keywordService *service.KeywordService) *KeywordHandler {
+	return &KeywordHandler{keywordService: keywordService}
+}
+
+// ExtractKeywordsRequest is the body of a keyword extraction
+type ExtractKeywordsRequest struct {
+	Text         string   `json:"text" binding:"required"`                        // Job description or other text
+	Limit        int      `json:"limit" binding:"omitempty,min=1"`                // Maximum number of keywords; all when omitted
+	PhraseWeight *float64 `json:"phraseWeight" binding:"omitempty,gt=0,lte=10"`   // Score multiplier of multi-word terms, 1.5 by default
+	Bigrams      *bool    `json:"bigrams"`                                        // Whether word pairs are extracted besides known phrases, true by default
+	Language     string   `json:"language" binding:"omitempty,oneof=en de fr es"` // Language of the text; detected when omitted
+}
+
+// IngestDocumentRequest is the body for adding a job description to the keyword corpus
+type IngestDocumentRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// MatchRequest is the body of an ATS match between a job description and a resume
+type MatchRequest struct {
+	JobDescription string `json:"jobDescription" binding:"required"`
+	Resume         string `json:"resume" binding:"required"`              // Markdown or plain text
+	Limit          int    `json:"limit" binding:"omitempty,min=1,max=50"` // Number of top keywords considered, 30 by default
+}
+
+// ExtractKeywords returns the ranked keywords of a text
+func (h *KeywordHandler) ExtractKeywords(c *gin.Context) {
+	var req ExtractKeywordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := service.DefaultKeywordOptions()
+	opts.Limit = req.Limit
+	if req.PhraseWeight != nil {
+		opts.PhraseWeight = *req.PhraseWeight
+	}
+	if req.Bigrams != nil {
+		opts.Bigrams = *req.Bigrams
+	}
+	opts.Language = req.Language
+	if opts.Language == "" {
+		opts.Language = h.keywordService.DetectLanguage(req.Text)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"language": opts.Language,
+		"keywords": h.keywordService.RankKeywords(c.Request.Context(), req.Text, opts),
+	})
+}
+
+// Match scores how well a resume covers the keywords of a job description
+func (h *KeywordHandler) Match(c *gin.Context) {
+	var req MatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.keywordService.MatchResume(c.Request.Context(), req.JobDescription, req.Resume, req.Limit))
+}
+
+// GetCorpusStats returns the totals of the corpus keywords are weighted against
+func (h *KeywordHandler) GetCorpusStats(c *gin.Context) {
+	stats, err := h.keywordService.CorpusStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// IngestDocument adds a job description to the keyword corpus
+func (h *KeywordHandler) IngestDocument(c *gin.Context) {
+	var req IngestDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	doc, err := h.keywordService.IngestJobDescription(c.Request.Context(), req.Text)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ingest document: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": doc.ID, "sourceId": doc.SourceID, "terms": len(doc.Keywords)})
+}
+
+// RecomputeStatistics rebuilds the document frequencies of the keyword corpus
+func (h *KeywordHandler) RecomputeStatistics(c *gin.Context) {
+	stats, err := h.keywordService.RecomputeStatistics(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recompute statistics: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
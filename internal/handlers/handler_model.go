@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikolai/ai-resume-builder/backend/internal/service"
+)
+
+// ModelHandler handles requests about the models of the LLM backend
+type ModelHandler struct {
+	modelService *service.ModelService
+}
+
+// NewModelHandler creates a new ModelHandler instance
+func NewModelHandler(modelService *service.ModelService) *ModelHandler {
+	return &ModelHandler{modelService: modelService}
+}
+
+// ListModels returns the models generations may pick and the default model
+func (h *ModelHandler) ListModels(c *gin.Context) {
+	models, err := h.modelService.ListModels(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to list models: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"models":  models,
+		"default": h.modelService.DefaultModel(),
+	})
+}
+
+// checkModel responds with an error and returns false when the backend does not have the
+// model. Requests are accepted when the models cannot be listed, e.g. while the backend
+// restarts; the generation then fails or waits like any other call to the backend.
+func checkModel(c *gin.Context, modelService *service.ModelService, model string) bool {
+	err := modelService.CheckModel(c.Request.Context(), model)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrModelNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Model %q is not installed on the LLM backend", model)})
+		return false
+	default:
+		log.Printf("Failed to list models, accepting model %q unchecked: %v", model, err)
+		return true
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repository"
+	"github.com/nikolai/ai-resume-builder/backend/internal/service"
+)
+
+// RefinementHandler handles requests about resume refinement sessions
+type RefinementHandler struct {
+	refinementService *service.RefinementService
+	resumeService     *service.ResumeService
+	modelService      *service.ModelService
+}
+
+// NewRefinementHandler creates a new RefinementHandler instance
+func NewRefinementHandler(refinementService *service.RefinementService, resumeService *service.ResumeService, modelService *service.ModelService) *RefinementHandler {
+	return &RefinementHandler{
+		refinementService: refinementService,
+		resumeService:     resumeService,
+		modelService:      modelService,
+	}
+}
+
+// RefineRequest is the body of a refinement turn
+type RefineRequest struct {
+	Instruction string                 `json:"instruction" binding:"required"`
+	Options     map[string]interface{} `json:"options"` // Same options as for generations
+}
+
+// RollbackRequest is the body for rolling a session back to an earlier turn
+type RollbackRequest struct {
+	Turn *int `json:"turn" binding:"required"` // 0 restores the content the session started with
+}
+
+// CreateSession starts a refinement session on a resume version
+func (h *RefinementHandler) CreateSession(c *gin.Context) {
+	userID, resumeID, ok := parseResumeParams(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.refinementService.CreateSession(c.Request.Context(), userID, resumeID)
+	if err != nil {
+		respondRefinementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// ListSessions returns the refinement sessions of a resume version
+func (h *RefinementHandler) ListSessions(c *gin.Context) {
+	userID, resumeID, ok := parseResumeParams(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.refinementService.ListSessions(c.Request.Context(), userID, resumeID)
+	if err != nil {
+		respondRefinementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetSession returns a refinement session with all its turns
+func (h *RefinementHandler) GetSession(c *gin.Context) {
+	userID, sessionID, ok := parseSessionParams(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.refinementService.GetSession(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		respondRefinementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session":        session,
+		"currentTurn":    session.CurrentTurn(),
+		"currentContent": session.CurrentContent(),
+	})
+}
+
+// DeleteSession deletes a refinement session
+func (h *RefinementHandler) DeleteSession(c *gin.Context) {
+	userID, sessionID, ok := parseSessionParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.refinementService.DeleteSession(c.Request.Context(), userID, sessionID); err != nil {
+		respondRefinementError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Refine streams the revision of the session's resume according to an instruction
+func (h *RefinementHandler) Refine(c *gin.Context) {
+	userID, sessionID, ok := parseSessionParams(c)
+	if !ok {
+		return
+	}
+
+	var req RefineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, err := h.resumeService.ParseGenerationOptions(req.Options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkModel(c, h.modelService, h.resumeService.GenerationModel(options)) {
+		return
+	}
+
+	// Report a missing session or an edited resume as a regular response before the stream starts
+	session, err := h.refinementService.GetSession(c.Request.Context(), userID, sessionID)
+	if err == nil {
+		err = h.refinementService.CheckSession(c.Request.Context(), session)
+	}
+	if err != nil {
+		respondRefinementError(c, err)
+		return
+	}
+
+	stream, err := newStreamWriter(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	stream.Write(StreamEvent{Type: EventMeta, Data: gin.H{"source": "llm", "sessionId": session.ID, "turn": session.CurrentTurn() + 1}})
+
+	turn, err := h.refinementService.Refine(c.Request.Context(), service.RefineParams{
+		UserID:      userID,
+		SessionID:   sessionID,
+		Instruction: req.Instruction,
+		Options:     options,
+		OnQueuePosition: func(position int) {
+			stream.Write(StreamEvent{Type: EventMeta, Data: gin.H{"queuePosition": position}})
+		},
+	}, func(chunk string, done bool) error {
+		if chunk == "" {
+			return nil
+		}
+		return stream.Write(StreamEvent{Type: EventChunk, Data: StreamChunk{Chunk: chunk}})
+	})
+	if err != nil {
+		stream.Write(StreamEvent{Type: EventError, Data: gin.H{"error": err.Error()}})
+		return
+	}
+
+	stream.Write(StreamEvent{Type: EventDone, Data: gin.H{"sessionId": session.ID, "turn": turn.Number}})
+}
+
+// Rollback discards the turns after an earlier turn and restores its resume content
+func (h *RefinementHandler) Rollback(c *gin.Context) {
+	userID, sessionID, ok := parseSessionParams(c)
+	if !ok {
+		return
+	}
+
+	var req RollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.refinementService.Rollback(c.Request.Context(), userID, sessionID, *req.Turn)
+	if err != nil {
+		respondRefinementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session":        session,
+		"currentTurn":    session.CurrentTurn(),
+		"currentContent": session.CurrentContent(),
+	})
+}
+
+// parseSessionParams extracts the user and session IDs from the path
+func parseSessionParams(c *gin.Context) (uint, uint, bool) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, 0, false
+	}
+	sessionID, err := strconv.ParseUint(c.Param("sessionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return 0, 0, false
+	}
+	return uint(userID), uint(sessionID), true
+}
+
+// respondRefinementError maps refinement service errors to HTTP responses
+func respondRefinementError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Refinement session not found"})
+	case errors.Is(err, repository.ErrResumeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+	case errors.Is(err, repository.ErrResumeChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrResumeNotMarkdown):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTurn):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
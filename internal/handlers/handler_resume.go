@@ -1,29 +1,70 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repository"
 	"github.com/nikolai/ai-resume-builder/backend/internal/service"
+	"github.com/nikolai/ai-resume-builder/backend/internal/utils"
 )
 
 type ResumeHandler struct {
 	resumeService *service.ResumeService
+	jobService    *service.JobService
+	modelService  *service.ModelService
 }
 
 type GenerateResumeRequest struct {
 	UserID         uint   `json:"userId" binding:"required"`
 	JobDescription string `json:"jobDescription" binding:"required"`
+	Name           string `json:"name"`
+	JobTitle       string `json:"jobTitle"`
+	Company        string `json:"company"`
+	Format         string `json:"format" binding:"omitempty,oneof=markdown json"`      // "json" returns a typed ResumeContent instead of a markdown stream
+	VerifyMode     string `json:"verifyMode" binding:"omitempty,oneof=flag strip off"` // Overrides RESUME_VERIFY_MODE
+
+	// Options overrides the model and its sampling parameters: model, temperature, top_p, num_ctx, seed and stop
+	Options map[string]interface{} `json:"options"`
+}
+
+// EditResumeRequest is the body of a targeted edit of a resume fragment
+type EditResumeRequest struct {
+	UserID      uint                   `json:"userId"` // Used to queue the edit fairly; optional
+	Content     string                 `json:"content" binding:"required"`
+	Selector    service.EditSelector   `json:"selector"`
+	Instruction string                 `json:"instruction" binding:"required"`
+	Options     map[string]interface{} `json:"options"`
+}
+
+// SimilarResumesRequest is the body of a search for the resumes closest to a job description
+type SimilarResumesRequest struct {
+	JobDescription string `json:"jobDescription" binding:"required"`
+	Limit          int    `json:"limit" binding:"omitempty,min=1,max=50"` // 5 by default
 }
 
-type StreamChunk struct {
-	Chunk string `json:"chunk"`
-	Done  bool   `json:"done"`
+// ResumeRequest is the body for creating or updating a resume version
+type ResumeRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	JobTitle    string `json:"jobTitle"`
+	Company     string `json:"company"`
+	Content     string `json:"content"`
+	Format      string `json:"format" binding:"omitempty,oneof=markdown json"`
+	IsDefault   bool   `json:"isDefault"`
+	SkillIDs    []uint `json:"skillIds"` // Ordered skill IDs; omit to keep the current skills on update
 }
 
-func NewResumeHandler(resumeService *service.ResumeService) *ResumeHandler {
-	return &ResumeHandler{resumeService: resumeService}
+func NewResumeHandler(resumeService *service.ResumeService, jobService *service.JobService, modelService *service.ModelService) *ResumeHandler {
+	return &ResumeHandler{
+		resumeService: resumeService,
+		jobService:    jobService,
+		modelService:  modelService,
+	}
 }
 
 // GenerateResume handles requests to generate a resume from a job description
@@ -34,59 +75,328 @@ func (h *ResumeHandler) GenerateResume(c *gin.Context) {
 		return
 	}
 
-	// Set headers for streaming
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("Transfer-Encoding", "chunked")
+	options, err := h.resumeService.ParseGenerationOptions(req.Options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkModel(c, h.modelService, h.resumeService.GenerationModel(options)) {
+		return
+	}
+
+	params := service.GenerateResumeParams{
+		UserID:         req.UserID,
+		JobDescription: req.JobDescription,
+		Name:           req.Name,
+		JobTitle:       req.JobTitle,
+		Company:        req.Company,
+		Options:        options,
+		VerifyMode:     req.VerifyMode,
+	}
 
-	// Create a flusher to ensure data is sent immediately
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Streaming not supported"})
+	if req.Format == models.ResumeFormatJSON {
+		h.generateStructuredResume(c, params)
 		return
 	}
 
-	// Stream the resume generation
-	err := h.resumeService.GenerateResume(c.Request.Context(), req.UserID, req.JobDescription, func(chunk string, done bool) error {
-		// Create the chunk response
-		chunkResponse := StreamChunk{
-			Chunk: chunk,
-			Done:  done,
-		}
+	// The generation runs as a background job so that it survives client disconnects
+	job, err := h.jobService.Submit(c.Request.Context(), params)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
 
-		// Convert to JSON
-		data, err := json.Marshal(chunkResponse)
-		if err != nil {
-			return err
-		}
+	// Clients asking for a stream get the job's events right away; others poll the job
+	accept := c.GetHeader("Accept")
+	if strings.Contains(accept, "text/event-stream") || strings.Contains(accept, "application/x-ndjson") {
+		streamJob(c, h.jobService, job.ID, "")
+		return
+	}
 
-		// Send the chunk to the client
-		_, err = c.Writer.Write(data)
-		if err != nil {
-			return err
-		}
-		c.Writer.Write([]byte("\n"))
-		flusher.Flush()
-
-		// If we're done, send the source information
-		if done {
-			// Send source information as the final message
-			sourceData, _ := json.Marshal(map[string]string{"source": "llm"})
-			c.Writer.Write(sourceData)
-			c.Writer.Write([]byte("\n"))
-			flusher.Flush()
-		}
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"jobId":     job.ID,
+		"status":    job.Status,
+		"statusUrl": "/api/v1/jobs/" + job.ID,
+		"streamUrl": "/api/v1/jobs/" + job.ID + "/events",
+	})
+}
+
+// EditResume rewrites a fragment of a resume and responds with a patch against the submitted
+// content, which the client can accept or reject
+func (h *ResumeHandler) EditResume(c *gin.Context) {
+	var req EditResumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, err := h.resumeService.ParseGenerationOptions(req.Options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkModel(c, h.modelService, h.resumeService.GenerationModel(options)) {
+		return
+	}
 
-		return nil
+	patch, content, err := h.resumeService.EditResume(c.Request.Context(), service.EditParams{
+		UserID:      req.UserID,
+		Content:     req.Content,
+		Selector:    req.Selector,
+		Instruction: req.Instruction,
+		Options:     options,
 	})
+	switch {
+	case errors.Is(err, service.ErrInvalidSelector):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrFragmentNotFound):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrLLMQueueFull):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrEmptyEdit):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"patch":   patch,
+		"content": content,
+	})
+}
+
+// generateStructuredResume generates a resume in JSON mode and responds with the typed content
+func (h *ResumeHandler) generateStructuredResume(c *gin.Context, params service.GenerateResumeParams) {
+	resume, content, err := h.resumeService.GenerateStructuredResume(c.Request.Context(), params)
+	if errors.Is(err, service.ErrLLMQueueFull) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"resume":        resume,
+		"resumeContent": content,
+		"findings":      resume.Findings,
+		"provenance":    resume.Provenance,
+	})
+}
+
+// DownloadPDF renders the posted resume content as a PDF document
+func (h *ResumeHandler) DownloadPDF(c *gin.Context) {
+	var content models.ResumeContent
+	if err := c.ShouldBindJSON(&content); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if content.PersonalInfo.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "personalInfo.name is required"})
+		return
+	}
+
+	writePDF(c, content)
+}
+
+// writePDF renders resume content and sends it as a file download
+func writePDF(c *gin.Context, content models.ResumeContent) {
+	pdf, err := utils.GeneratePDF(content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+utils.PDFFilename(content.PersonalInfo.Name)+`"`)
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// ListResumes returns all resume versions of a user
+func (h *ResumeHandler) ListResumes(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	resumes, err := h.resumeService.ListResumes(c.Request.Context(), uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+	c.JSON(http.StatusOK, resumes)
+}
+
+// SimilarResumes returns the resume versions of a user that best match a job description
+func (h *ResumeHandler) SimilarResumes(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req SimilarResumesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	similar, err := h.resumeService.SimilarResumes(c.Request.Context(), uint(userID), req.JobDescription, req.Limit)
+	if errors.Is(err, service.ErrEmptyEmbedding) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"resumes": similar})
+}
+
+// CreateResume saves a new resume version for a user
+func (h *ResumeHandler) CreateResume(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req ResumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	resume := req.toResume()
+	resume.UserID = uint(userID)
+	if err := h.resumeService.CreateResume(c.Request.Context(), resume); err != nil {
+		respondResumeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resume)
+}
+
+// GetResume returns a single resume version of a user
+func (h *ResumeHandler) GetResume(c *gin.Context) {
+	userID, resumeID, ok := parseResumeParams(c)
+	if !ok {
+		return
+	}
+
+	resume, err := h.resumeService.GetResume(c.Request.Context(), userID, resumeID)
 	if err != nil {
-		// If there's an error after we've started streaming, we can't use regular error responses
-		// Just log the error and close the connection
-		errorData, _ := json.Marshal(map[string]string{"error": err.Error()})
-		c.Writer.Write(errorData)
-		c.Writer.Write([]byte("\n"))
-		flusher.Flush()
+		respondResumeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resume)
+}
+
+// UpdateResume updates a resume version of a user
+func (h *ResumeHandler) UpdateResume(c *gin.Context) {
+	userID, resumeID, ok := parseResumeParams(c)
+	if !ok {
+		return
+	}
+
+	var req ResumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	resume := req.toResume()
+	resume.ID = resumeID
+	resume.UserID = userID
+	if err := h.resumeService.UpdateResume(c.Request.Context(), resume); err != nil {
+		respondResumeError(c, err)
+		return
+	}
+
+	updated, err := h.resumeService.GetResume(c.Request.Context(), userID, resumeID)
+	if err != nil {
+		respondResumeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteResume deletes a resume version of a user
+func (h *ResumeHandler) DeleteResume(c *gin.Context) {
+	userID, resumeID, ok := parseResumeParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.resumeService.DeleteResume(c.Request.Context(), userID, resumeID); err != nil {
+		respondResumeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// toResume converts the request body into a resume model
+func (r ResumeRequest) toResume() *models.Resume {
+	resume := &models.Resume{
+		Name:        r.Name,
+		Description: r.Description,
+		JobTitle:    r.JobTitle,
+		Company:     r.Company,
+		Content:     r.Content,
+		Format:      r.Format,
+		IsDefault:   r.IsDefault,
+	}
+	if r.SkillIDs != nil {
+		resume.Skills = make([]models.ResumeSkill, 0, len(r.SkillIDs))
+		for i, skillID := range r.SkillIDs {
+			resume.Skills = append(resume.Skills, models.ResumeSkill{SkillID: skillID, Order: i})
+		}
+	}
+	return resume
+}
+
+// parseResumeParams reads the user and resume IDs from the path, writing a 400 response when invalid
+func parseResumeParams(c *gin.Context) (uint, uint, bool) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, 0, false
+	}
+	resumeID, err := strconv.ParseUint(c.Param("resumeId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resume ID"})
+		return 0, 0, false
+	}
+	return uint(userID), uint(resumeID), true
+}
+
+// respondResumeError maps resume service errors to HTTP responses
+func respondResumeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrResumeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+	case errors.Is(err, repository.ErrResumeUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, repository.ErrResumeSkillNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown skill ID"})
+	case errors.Is(err, service.ErrResumeUserRequired),
+		errors.Is(err, service.ErrResumeIDRequired),
+		errors.Is(err, service.ErrResumeNameRequired),
+		errors.Is(err, service.ErrInvalidResumeContent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
 }
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/llmtest"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repotest"
+	"github.com/nikolai/ai-resume-builder/backend/internal/service"
+)
+
+// generateTestServer serves the generate endpoint on in-memory repositories, with a job
+// worker generating through the Ollama provider against a fake Ollama server
+type generateTestServer struct {
+	*httptest.Server
+	resumes *repotest.Resumes
+	jobs    *repotest.Jobs
+}
+
+func newGenerateTestServer(t *testing.T, opts llmtest.OllamaServerOptions) *generateTestServer {
+	t.Helper()
+	opts.Model = "llama3.2"
+	ollama := llmtest.NewOllamaServer(opts)
+	t.Cleanup(ollama.Close)
+	provider := service.NewOllamaProvider(&http.Client{}, ollama.URL+"/api")
+
+	users := repotest.NewUsers(repotest.User())
+	resumes := repotest.NewResumes(users)
+	jobs := repotest.NewJobs()
+
+	llmConfig := &config.LLMConfig{Model: "llama3.2", VerifyMode: config.VerifyModeFlag}
+	keywordService := service.NewKeywordService(nil, repotest.NewKeywords(), repotest.NewSkills(), config.NewKeywordConfig(), service.NewHashingEmbedder(64))
+	userService := service.NewUserService(users, nil)
+	resumeService := service.NewResumeService(nil, resumes, keywordService, provider, userService, llmConfig)
+	jobService := service.NewJobService(jobs, resumeService, &config.JobConfig{
+		Workers:       1,
+		QueueSize:     10,
+		UserQueueSize: 10,
+		MaxAttempts:   1,
+		RetryBackoff:  time.Second,
+		PollInterval:  50 * time.Millisecond,
+		StaleAfter:    time.Minute,
+		Retention:     time.Minute,
+	})
+	jobService.Start()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		jobService.Shutdown(ctx)
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewResumeHandler(resumeService, jobService, service.NewModelService(provider, llmConfig))
+	router.POST("/api/v1/generate", handler.GenerateResume)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return &generateTestServer{Server: server, resumes: resumes, jobs: jobs}
+}
+
+// receivedEvent is an event of a generation stream with the time it arrived
+type receivedEvent struct {
+	Type string
+	Data map[string]interface{}
+	At   time.Time
+}
+
+// generate posts a generation asking for Server-Sent Events and returns the events received
+func (s *generateTestServer) generate(t *testing.T) []receivedEvent {
+	t.Helper()
+	body := `{"userId": 1, "jobDescription": "` + repotest.JobDescription + `"}`
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/generate", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /generate: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("got status %d and content type %q, want an event stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var events []receivedEvent
+	var event receivedEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event.Type = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data); err != nil {
+				t.Fatalf("invalid event data %q: %v", line, err)
+			}
+		case line == "" && event.Type != "":
+			event.At = time.Now()
+			events = append(events, event)
+			event = receivedEvent{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading the stream: %v", err)
+	}
+	return events
+}
+
+// checkJobStatus checks the status of the job whose events were received
+func (s *generateTestServer) checkJobStatus(t *testing.T, events []receivedEvent, want string) {
+	t.Helper()
+	jobID, _ := events[0].Data["jobId"].(string)
+	job, err := s.jobs.GetJob(context.Background(), jobID)
+	if err != nil {
+		t.Fatalf("GetJob(%q): %v", jobID, err)
+	}
+	if job.Status != want {
+		t.Errorf("job has status %q, want %q", job.Status, want)
+	}
+}
+
+// streamedContent joins the chunks of a stream
+func streamedContent(events []receivedEvent) string {
+	var content strings.Builder
+	for _, event := range events {
+		if event.Type == EventChunk {
+			chunk, _ := event.Data["chunk"].(string)
+			content.WriteString(chunk)
+		}
+	}
+	return content.String()
+}
+
+func TestGenerateResumeStream(t *testing.T) {
+	server := newGenerateTestServer(t, llmtest.OllamaServerOptions{Chunks: strings.SplitAfter(repotest.Resume, " ")})
+
+	events := server.generate(t)
+	if len(events) == 0 {
+		t.Fatal("got no events")
+	}
+	if events[0].Type != EventMeta || events[0].Data["jobId"] == nil {
+		t.Errorf("first event is %+v, want a meta event with the job ID", events[0])
+	}
+	last := events[len(events)-1]
+	if last.Type != EventDone {
+		t.Fatalf("last event is %+v, want done", last)
+	}
+	if last.Data["resumeId"] == nil {
+		t.Errorf("done event lacks the saved resume: %+v", last.Data)
+	}
+	if provenance, _ := last.Data["provenance"].([]interface{}); len(provenance) != 3 {
+		t.Errorf("done event has provenance %v, want the 3 cited bullet points", last.Data["provenance"])
+	}
+
+	content := streamedContent(events)
+	if strings.Contains(content, "[src:") {
+		t.Errorf("source markers were streamed:\n%s", content)
+	}
+	if !strings.HasPrefix(content, "# Jane Doe") || !strings.Contains(content, "- Split the billing platform into Go microservices") {
+		t.Errorf("streamed content is incomplete:\n%s", content)
+	}
+
+	saved := server.resumes.All()
+	if len(saved) != 1 {
+		t.Fatalf("got %d saved resumes, want 1", len(saved))
+	}
+	if saved[0].Content != strings.TrimSpace(content) && saved[0].Content != content {
+		t.Errorf("saved content %q differs from the stream %q", saved[0].Content, content)
+	}
+	server.checkJobStatus(t, events, models.JobStatusSucceeded)
+}
+
+func TestGenerateResumeStreamFailure(t *testing.T) {
+	server := newGenerateTestServer(t, llmtest.OllamaServerOptions{
+		Chunks:    strings.SplitAfter(repotest.Resume, " "),
+		FailAfter: 4,
+	})
+
+	events := server.generate(t)
+	last := events[len(events)-1]
+	if last.Type != EventError {
+		t.Fatalf("last event is %+v, want an error", last)
+	}
+	for _, event := range events {
+		if event.Type == EventDone {
+			t.Errorf("got a done event for a failed generation")
+		}
+	}
+	if content := streamedContent(events); content == "" || strings.Contains(content, "Split the billing") {
+		t.Errorf("got content %q, want only the chunks sent before the failure", content)
+	}
+	if saved := server.resumes.All(); len(saved) != 0 {
+		t.Errorf("saved %d resumes after a failed generation", len(saved))
+	}
+	server.checkJobStatus(t, events, models.JobStatusFailed)
+}
+
+func TestGenerateResumeStreamLatency(t *testing.T) {
+	const latency = 20 * time.Millisecond
+	chunks := strings.SplitAfter(repotest.Resume, "\n")
+	server := newGenerateTestServer(t, llmtest.OllamaServerOptions{Chunks: chunks, Latency: latency})
+
+	events := server.generate(t)
+	var first, done time.Time
+	for _, event := range events {
+		if event.Type == EventChunk && first.IsZero() {
+			first = event.At
+		}
+		if event.Type == EventDone {
+			done = event.At
+		}
+	}
+	if first.IsZero() || done.IsZero() {
+		t.Fatalf("got events %+v, want chunks and a done event", events)
+	}
+	// The chunks reach the client as the model produces them, not in one piece at the end
+	if gap := done.Sub(first); gap < time.Duration(len(chunks)/2)*latency {
+		t.Errorf("first chunk arrived %v before the end, want the output streamed over %d chunks of %v", gap, len(chunks), latency)
+	}
+}
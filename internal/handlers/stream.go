@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Types of the events sent on generation streams
+const (
+	EventChunk = "chunk" // A piece of generated text
+	EventDone  = "done"  // Generation finished; carries the saved resume
+	EventError = "error" // Generation failed; no further events follow
+	EventMeta  = "meta"  // Information about the stream itself, such as its source
+)
+
+const (
+	// sseRetry is the reconnection delay suggested to EventSource clients
+	sseRetry = 3 * time.Second
+	// sseHeartbeatInterval is how often a comment is sent to keep idle connections open
+	sseHeartbeatInterval = 15 * time.Second
+)
+
+// StreamEvent is a typed event of a generation stream
+type StreamEvent struct {
+	ID   string      // Assigned sequentially when empty
+	Type string      // One of the Event* constants
+	Data interface{} // JSON encoded payload
+}
+
+// StreamChunk is the payload of a chunk event
+type StreamChunk struct {
+	Chunk string `json:"chunk"`
+}
+
+// streamWriter sends events to a streaming response
+type streamWriter interface {
+	Write(event StreamEvent) error
+	Close()
+}
+
+// newStreamWriter prepares a streaming response: Server-Sent Events by default, or NDJSON when
+// the client asks for application/x-ndjson in its Accept header
+func newStreamWriter(c *gin.Context) (streamWriter, error) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		return nil, errors.New("Streaming not supported")
+	}
+
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+
+	if strings.Contains(c.GetHeader("Accept"), "application/x-ndjson") {
+		c.Writer.Header().Set("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		return &ndjsonWriter{w: c.Writer, flusher: flusher}, nil
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Status(http.StatusOK)
+	w := &sseWriter{w: c.Writer, flusher: flusher, stop: make(chan struct{})}
+	if err := w.writeRaw(fmt.Sprintf("retry: %d\n\n", sseRetry.Milliseconds())); err != nil {
+		return nil, err
+	}
+	go w.heartbeat(sseHeartbeatInterval)
+	return w, nil
+}
+
+// sseWriter frames events as Server-Sent Events and sends periodic heartbeat comments
+type sseWriter struct {
+	mu      sync.Mutex
+	w       gin.ResponseWriter
+	flusher http.Flusher
+	lastID  int
+	stop    chan struct{}
+	closed  bool
+}
+
+// Write sends an event with id, event and data fields
+func (s *sseWriter) Write(event StreamEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := event.ID
+	if id == "" {
+		s.lastID++
+		id = strconv.Itoa(s.lastID)
+	}
+
+	var frame strings.Builder
+	frame.WriteString("id: " + id + "\n")
+	frame.WriteString("event: " + event.Type + "\n")
+	// JSON never contains raw newlines, so the payload always fits a single data line
+	frame.WriteString("data: " + string(data) + "\n\n")
+	return s.writeLocked(frame.String())
+}
+
+// Close stops the heartbeat
+func (s *sseWriter) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+	}
+}
+
+// heartbeat writes a comment line every interval until the writer is closed
+func (s *sseWriter) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.writeRaw(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *sseWriter) writeRaw(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeLocked(text)
+}
+
+func (s *sseWriter) writeLocked(text string) error {
+	if s.closed {
+		return errors.New("stream closed")
+	}
+	if _, err := s.w.WriteString(text); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// ndjsonWriter writes events as JSON lines in the format /generate streamed before Server-Sent
+// Events were introduced, so that existing clients keep working: {"chunk":..,"done":false}
+// for each piece of text, {"chunk":"","done":true} followed by {"source":"llm","resumeId":..}
+// at the end, and the payload as it is for errors and meta events. Lines carry no event IDs,
+// so NDJSON streams cannot be resumed.
+type ndjsonWriter struct {
+	w       gin.ResponseWriter
+	flusher http.Flusher
+}
+
+// ndjsonChunk is the line format of chunks and of the end of the text
+type ndjsonChunk struct {
+	Chunk string `json:"chunk"`
+	Done  bool   `json:"done"`
+}
+
+// Write sends an event as one or two lines
+func (n *ndjsonWriter) Write(event StreamEvent) error {
+	switch event.Type {
+	case EventChunk:
+		var chunk StreamChunk
+		if err := convertPayload(event.Data, &chunk); err != nil {
+			return err
+		}
+		return n.writeLine(ndjsonChunk{Chunk: chunk.Chunk})
+
+	case EventDone:
+		if err := n.writeLine(ndjsonChunk{Done: true}); err != nil {
+			return err
+		}
+		result := map[string]interface{}{}
+		if err := convertPayload(event.Data, &result); err != nil {
+			return err
+		}
+		result["source"] = "llm"
+		return n.writeLine(result)
+	}
+	return n.writeLine(event.Data)
+}
+
+// writeLine sends a value as a single JSON line
+func (n *ndjsonWriter) writeLine(value interface{}) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if _, err := n.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	n.flusher.Flush()
+	return nil
+}
+
+// convertPayload decodes an event payload of any type into out through its JSON encoding
+func convertPayload(data, out interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if string(encoded) == "null" {
+		return nil
+	}
+	return json.Unmarshal(encoded, out)
+}
+
+// Close is a no-op; NDJSON streams have no heartbeat
+func (n *ndjsonWriter) Close() {}
@@ -0,0 +1,64 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// UserRepository stores users with their work experience and education
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	CreateUserTx(ctx context.Context, tx Tx, user *models.User) error
+	CreateWorkExperiencesTx(ctx context.Context, tx Tx, experiences []models.WorkExperience) error
+	CreateEducationsTx(ctx context.Context, tx Tx, educations []models.Education) error
+	GetUserByID(ctx context.Context, id uint) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserWithDetails(ctx context.Context, id uint) (*models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+}
+
+// ResumeRepository stores the resume versions of users
+type ResumeRepository interface {
+	CreateResume(ctx context.Context, resume *models.Resume) error
+	GetResumesByUserID(ctx context.Context, userID uint) ([]models.Resume, error)
+	GetResumeByID(ctx context.Context, userID, resumeID uint) (*models.Resume, error)
+	UpdateResume(ctx context.Context, resume *models.Resume) error
+	DeleteResume(ctx context.Context, userID, resumeID uint) error
+}
+
+// KeywordRepository stores keyword vectors of job descriptions and resumes with the
+// statistics of the corpus of job descriptions
+type KeywordRepository interface {
+	UpsertDocument(ctx context.Context, doc *models.KeywordVector) error
+	GetDocuments(ctx context.Context, sourceType string, sourceIDs []string) ([]models.KeywordVector, error)
+	ListDocuments(ctx context.Context, sourceType string, afterID, limit int) ([]models.KeywordVector, error)
+	UpdateKeywords(ctx context.Context, id int, keywords models.KeywordList) error
+	DeleteDocument(ctx context.Context, sourceType, sourceID string) error
+	FindNearest(ctx context.Context, embedding models.Vector, embeddingModel, sourceType string, sourceIDs []string, limit int) ([]models.KeywordVectorResult, error)
+	GetEmbeddingDimensions(ctx context.Context) (int, error)
+	GetDocumentFrequencies(ctx context.Context, terms []string) (map[string]int, error)
+	GetCorpusStats(ctx context.Context) (*models.KeywordCorpusStats, error)
+	RecomputeStatistics(ctx context.Context) (*models.KeywordCorpusStats, error)
+}
+
+// SkillRepository stores the known skills
+type SkillRepository interface {
+	UpsertSkills(ctx context.Context, skills []models.Skill) error
+}
+
+// JobRepository stores the queue of generation jobs
+type JobRepository interface {
+	CreateJob(ctx context.Context, job *models.GenerationJob, maxQueued, maxUserQueued int) error
+	GetJob(ctx context.Context, id string) (*models.GenerationJob, error)
+	ListQueuedJobs(ctx context.Context) ([]models.GenerationJob, error)
+	ClaimNextJob(ctx context.Context, workerID string) (*models.GenerationJob, error)
+	TouchJob(ctx context.Context, id, workerID string) error
+	CompleteJob(ctx context.Context, id, workerID string, resumeID uint, result string) error
+	FailJob(ctx context.Context, id, workerID, message string, retryAt *time.Time) error
+	CancelRunningJob(ctx context.Context, id, workerID string) error
+	RequeueJob(ctx context.Context, id, workerID string) error
+	CancelQueuedJob(ctx context.Context, id string) (bool, error)
+	RequeueStaleJobs(ctx context.Context, staleBefore time.Time) (requeued, failed int64, err error)
+}
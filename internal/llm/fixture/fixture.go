@@ -0,0 +1,195 @@
+// Package fixture records LLM exchanges to files and replays them without a model or network
+// access, so that generations can be reproduced in development and tests.
+package fixture
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Fixture describes a recorded LLM exchange. The response body is stored byte for byte
+// in a sibling file named after the fixture key with a ".body" extension.
+type Fixture struct {
+	Method  string          `json:"method"`
+	Path    string          `json:"path"`
+	Request json.RawMessage `json:"request,omitempty"` // Canonical JSON request body
+	Status  int             `json:"status"`
+	Header  http.Header     `json:"header"`
+}
+
+// RecordingTransport is an http.RoundTripper that forwards requests to Base and writes
+// every exchange, including streamed responses, to fixture files in Dir
+type RecordingTransport struct {
+	Base http.RoundTripper
+	Dir  string
+}
+
+// NewRecordingTransport creates a transport recording the traffic of base into dir
+func NewRecordingTransport(base http.RoundTripper, dir string) *RecordingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RecordingTransport{Base: base, Dir: dir}
+}
+
+// RoundTrip sends the request and tees the response body into a fixture as it is read
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key, canonical := Key(req.Method, req.URL.Path, body)
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := Fixture{
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Request: canonical,
+		Status:  resp.StatusCode,
+		Header:  resp.Header.Clone(),
+	}
+	resp.Body = &recordingBody{
+		body: resp.Body,
+		save: func(data []byte) error { return writeFixture(t.Dir, key, fixture, data) },
+	}
+	return resp, nil
+}
+
+// recordingBody copies everything read from body and saves it once the body is exhausted or closed
+type recordingBody struct {
+	body io.ReadCloser
+	buf  bytes.Buffer
+	save func(data []byte) error
+	once sync.Once
+	err  error
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.persist()
+	}
+	return n, err
+}
+
+// Close drains the rest of the response so that the fixture is complete, then saves it
+func (b *recordingBody) Close() error {
+	if _, err := io.Copy(&b.buf, b.body); err == nil {
+		b.persist()
+	}
+	closeErr := b.body.Close()
+	if b.err != nil {
+		return b.err
+	}
+	return closeErr
+}
+
+func (b *recordingBody) persist() {
+	b.once.Do(func() {
+		b.err = b.save(b.buf.Bytes())
+	})
+}
+
+// ReplayTransport is an http.RoundTripper serving responses recorded by RecordingTransport
+// from Dir without any network access
+type ReplayTransport struct {
+	Dir string
+}
+
+// NewReplayTransport creates a transport replaying the fixtures in dir
+func NewReplayTransport(dir string) *ReplayTransport {
+	return &ReplayTransport{Dir: dir}
+}
+
+// RoundTrip looks up the fixture matching the request and returns its recorded response
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key, _ := Key(req.Method, req.URL.Path, body)
+
+	data, err := os.ReadFile(filepath.Join(t.Dir, key+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("fixture: no fixture for %s %s (key %s): %v", req.Method, req.URL.Path, key, err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("fixture: invalid fixture %s: %v", key, err)
+	}
+	responseBody, err := os.ReadFile(filepath.Join(t.Dir, key+".body"))
+	if err != nil {
+		return nil, fmt.Errorf("fixture: missing body of fixture %s: %v", key, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        fixture.Header,
+		Body:          io.NopCloser(bytes.NewReader(responseBody)),
+		ContentLength: int64(len(responseBody)),
+		Request:       req,
+	}, nil
+}
+
+// Key identifies an exchange by method, path and request body. JSON bodies are
+// canonicalized so that field order and whitespace do not matter; the host is ignored so
+// fixtures recorded against one server replay against any base URL.
+func Key(method, path string, body []byte) (string, json.RawMessage) {
+	var canonical json.RawMessage
+	var decoded interface{}
+	if len(body) > 0 && json.Unmarshal(body, &decoded) == nil {
+		canonical, _ = json.Marshal(decoded)
+		body = canonical
+	}
+
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))[:16], canonical
+}
+
+// readRequestBody reads the request body and restores it so the request can still be sent
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// writeFixture stores the fixture metadata and the raw response body in dir
+func writeFixture(dir, key string, fixture Fixture, body []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	meta, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, key+".body"), body, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, key+".json"), meta, 0o644)
+}
@@ -0,0 +1,168 @@
+// Package llmtest provides stand-ins for LLM backends so that services and handlers
+// can be exercised without a model or network access.
+package llmtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OllamaServerOptions configures the behaviour of a fake Ollama server
+type OllamaServerOptions struct {
+	Chunks    []string      // Response chunks streamed in order; a single default chunk when empty
+	Model     string        // Model name echoed in responses
+	Models    []string      // Models listed by /api/tags; only Model when empty
+	Latency   time.Duration // Delay before each streamed chunk
+	Status    int           // When set to a non-200 status, every request fails with it
+	FailAfter int           // Close the stream without a final done message after this many chunks when greater than zero
+}
+
+// OllamaServer is an httptest server speaking Ollama's /api/generate and /api/chat protocols,
+// including NDJSON streaming, and listing its models on /api/tags
+type OllamaServer struct {
+	*httptest.Server
+
+	opts     OllamaServerOptions
+	mu       sync.Mutex
+	requests []GenerateRequest
+}
+
+// GenerateRequest is a request received by the fake Ollama server. Chat requests
+// carry Messages instead of a Prompt.
+type GenerateRequest struct {
+	Model    string    `json:"model"`
+	Prompt   string    `json:"prompt,omitempty"`
+	Messages []Message `json:"messages,omitempty"`
+	Stream   bool      `json:"stream"`
+	Format   string    `json:"format,omitempty"`
+}
+
+// Message is a message of a chat request or response
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// generateResponse is a response line of the Ollama generate and chat APIs
+type generateResponse struct {
+	Model     string   `json:"model"`
+	CreatedAt string   `json:"created_at"`
+	Response  string   `json:"response,omitempty"`
+	Message   *Message `json:"message,omitempty"`
+	Done      bool     `json:"done"`
+}
+
+// NewOllamaServer starts a fake Ollama server. Point an Ollama provider at server.URL + "/api"
+// and call Close when done.
+func NewOllamaServer(opts OllamaServerOptions) *OllamaServer {
+	if len(opts.Chunks) == 0 {
+		opts.Chunks = []string{"Hello from the fake Ollama server."}
+	}
+	if opts.Model == "" {
+		opts.Model = "fake"
+	}
+
+	s := &OllamaServer{opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/generate", s.handleGenerate)
+	mux.HandleFunc("/api/chat", s.handleGenerate)
+	mux.HandleFunc("/api/tags", s.handleTags)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Requests returns the generate and chat requests received so far
+func (s *OllamaServer) Requests() []GenerateRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]GenerateRequest(nil), s.requests...)
+}
+
+func (s *OllamaServer) handleTags(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Status != 0 && s.opts.Status != http.StatusOK {
+		http.Error(w, `{"error":"fake failure"}`, s.opts.Status)
+		return
+	}
+
+	names := s.opts.Models
+	if len(names) == 0 {
+		names = []string{s.opts.Model}
+	}
+	type tag struct {
+		Name       string `json:"name"`
+		Model      string `json:"model"`
+		ModifiedAt string `json:"modified_at"`
+	}
+	tags := make([]tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, tag{Name: name, Model: name, ModifiedAt: time.Now().UTC().Format(time.RFC3339)})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"models": tags})
+}
+
+func (s *OllamaServer) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req GenerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	if s.opts.Status != 0 && s.opts.Status != http.StatusOK {
+		http.Error(w, `{"error":"fake failure"}`, s.opts.Status)
+		return
+	}
+
+	chat := r.URL.Path == "/api/chat"
+	if !req.Stream {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.response(chat, strings.Join(s.opts.Chunks, ""), true))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	for i, chunk := range s.opts.Chunks {
+		if s.opts.FailAfter > 0 && i == s.opts.FailAfter {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(s.opts.Latency):
+		}
+		encoder.Encode(s.response(chat, chunk, false))
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	encoder.Encode(s.response(chat, "", true))
+}
+
+func (s *OllamaServer) response(chat bool, text string, done bool) generateResponse {
+	resp := generateResponse{
+		Model:     s.opts.Model,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		Done:      done,
+	}
+	if chat {
+		resp.Message = &Message{Role: "assistant", Content: text}
+	} else {
+		resp.Response = text
+	}
+	return resp
+}
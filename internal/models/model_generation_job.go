@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Statuses of a generation job
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCanceled  = "canceled"
+)
+
+// GenerationJob is a queued resume generation, persisted so that it survives restarts
+type GenerationJob struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"userId" gorm:"not null"`
+	Params      string     `json:"-" gorm:"type:jsonb;not null"` // JSON encoded generation parameters
+	Status      string     `json:"status" gorm:"not null;default:queued"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"maxAttempts"`
+	RunAfter    time.Time  `json:"runAfter"`
+	ResumeID    *uint      `json:"resumeId,omitempty"`
+	Result      string     `json:"result,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	LockedBy    string     `json:"-"`
+	LockedAt    *time.Time `json:"-"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"` // When the last attempt was claimed
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// Finished reports whether the job reached a terminal status
+func (j *GenerationJob) Finished() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed || j.Status == JobStatusCanceled
+}
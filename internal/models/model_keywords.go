@@ -1,17 +1,168 @@
 package models
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Keyword represents a single keyword and its metadata
 type Keyword struct {
-	Word  string  `json:"word"`
-	Score float64 `json:"score"`
-	Count int     `json:"count"`
+	Word     string  `json:"word"` // Display form: the most frequent spelling in the text
+	Term     string  `json:"term"` // Stemmed or canonical form that counts and statistics are keyed by
+	Score    float64 `json:"score"`
+	Count    int     `json:"count"`
+	Category string  `json:"category,omitempty"` // Category of the skill the keyword names, if any
+}
+
+// Kinds of documents in the keyword_vectors table
+const (
+	KeywordSourceJob    = "job"
+	KeywordSourceResume = "resume"
+)
+
+// KeywordList is stored as a JSON array
+type KeywordList []Keyword
+
+// Value implements driver.Valuer
+func (k KeywordList) Value() (driver.Value, error) {
+	if k == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (k *KeywordList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*k = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, k)
+	case string:
+		return json.Unmarshal([]byte(v), k)
+	}
+	return errors.New("unsupported type for keywords")
+}
+
+// Vector is a pgvector value, exchanged in its text form "[1,2,3]"
+type Vector []float32
+
+// Value implements driver.Valuer
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	parts := make([]string, len(v))
+	for i, x := range v {
+		parts[i] = strconv.FormatFloat(float64(x), 'f', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]", nil
+}
+
+// Scan implements sql.Scanner
+func (v *Vector) Scan(value interface{}) error {
+	var text string
+	switch x := value.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		text = string(x)
+	case string:
+		text = x
+	default:
+		return errors.New("unsupported type for vector")
+	}
+
+	text = strings.Trim(strings.TrimSpace(text), "[]")
+	vector := Vector{}
+	if text != "" {
+		for _, part := range strings.Split(text, ",") {
+			x, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+			if err != nil {
+				return fmt.Errorf("invalid vector component %q: %v", part, err)
+			}
+			vector = append(vector, float32(x))
+		}
+	}
+	*v = vector
+	return nil
+}
+
+// KeywordVector is a document of the keyword corpus with all of its terms
+type KeywordVector struct {
+	ID             int         `json:"id" gorm:"primaryKey"`
+	SourceType     string      `json:"sourceType"` // KeywordSourceJob or KeywordSourceResume
+	SourceID       string      `json:"sourceId"`   // Content hash for job descriptions, resume ID for resumes
+	Text           string      `json:"text"`
+	Keywords       KeywordList `json:"keywords" gorm:"type:jsonb"`
+	Embedding      Vector      `json:"-" gorm:"column:vector_embedding;type:vector"`
+	EmbeddingModel string      `json:"embeddingModel,omitempty"` // Embedder that produced Embedding
+	CreatedAt      time.Time   `json:"createdAt"`
+}
+
+// TermCount returns the number of terms of the document
+func (v *KeywordVector) TermCount() int {
+	total := 0
+	for _, k := range v.Keywords {
+		total += k.Count
+	}
+	return total
+}
+
+// KeywordDocumentFrequency is the number of job descriptions of the corpus a term occurs in
+type KeywordDocumentFrequency struct {
+	Term          string `gorm:"primaryKey"`
+	DocumentCount int
+}
+
+// KeywordCorpusStats holds the totals of the keyword corpus
+type KeywordCorpusStats struct {
+	ID            int       `json:"-" gorm:"primaryKey"`
+	DocumentCount int       `json:"documentCount"`
+	TermCount     int64     `json:"termCount"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// AverageLength returns the average number of terms of a document
+func (s *KeywordCorpusStats) AverageLength() float64 {
+	if s.DocumentCount == 0 {
+		return 0
+	}
+	return float64(s.TermCount) / float64(s.DocumentCount)
 }
 
 // KeywordVectorResult represents a document with keywords and similarity score
 type KeywordVectorResult struct {
-	ID         int       `json:"id"`
-	SourceType string    `json:"source_type"`
-	SourceID   string    `json:"source_id"`
-	Keywords   []Keyword `json:"keywords"`
-	Similarity float64   `json:"similarity"`
+	ID         int         `json:"id"`
+	SourceType string      `json:"source_type"`
+	SourceID   string      `json:"source_id"`
+	Keywords   KeywordList `json:"keywords"`
+	Similarity float64     `json:"similarity"` // Cosine similarity of the keyword vectors, from -1 to 1
+}
+
+// KeywordMatch is a keyword of a job description and how often a resume mentions it
+type KeywordMatch struct {
+	Word        string  `json:"word"`
+	Weight      float64 `json:"weight"`      // Share of the total keyword weight of the job description
+	Count       int     `json:"count"`       // Occurrences in the job description
+	ResumeCount int     `json:"resumeCount"` // Occurrences in the resume
+}
+
+// MatchResult rates how well a resume covers the keywords of a job description
+type MatchResult struct {
+	Score    float64        `json:"score"`    // Weighted keyword coverage from 0 to 100
+	Coverage float64        `json:"coverage"` // Share of the keywords the resume mentions, from 0 to 1
+	Matched  []KeywordMatch `json:"matched"`  // Keywords the resume mentions, most important first
+	Missing  []KeywordMatch `json:"missing"`  // Keywords the resume lacks, most important first
 }
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Kinds of profile records generated content is based on
+const (
+	SourceWorkExperience = "workExperience"
+	SourceEducation      = "education"
+)
+
+// SourceRef links generated content to a record of the user's profile
+type SourceRef struct {
+	Kind  string `json:"kind"`
+	ID    uint   `json:"id"`
+	Label string `json:"label"` // e.g. "Acme Corp, 2021–2023"
+}
+
+// BulletProvenance records the profile records a generated bullet point is based on
+type BulletProvenance struct {
+	Text     string      `json:"text"`
+	Location string      `json:"location"`          // "line 12" in markdown, a field path such as "experience[1].description[0]" in JSON
+	Sources  []SourceRef `json:"sources"`           // Empty when the model cited no valid record
+	Invalid  []string    `json:"invalid,omitempty"` // References the model made to records the user does not have
+}
+
+// Provenance is stored as a JSON array
+type Provenance []BulletProvenance
+
+// Value implements driver.Valuer
+func (p Provenance) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (p *Provenance) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	}
+	return errors.New("unsupported type for provenance")
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// RefinementSession is a conversation in which a user revises a resume version turn by turn
+type RefinementSession struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"userId" gorm:"not null"`
+	ResumeID    uint      `json:"resumeId" gorm:"not null"`
+	BaseContent string    `json:"baseContent" gorm:"type:text;not null"` // Resume content when the session started
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	Turns []RefinementTurn `json:"turns" gorm:"foreignKey:SessionID"`
+}
+
+// RefinementTurn is a revision of the resume requested by the user
+type RefinementTurn struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	SessionID   uint      `json:"sessionId" gorm:"not null"`
+	Number      int       `json:"number" gorm:"not null"`      // 1 for the first turn; turn 0 is the base content
+	Instruction string    `json:"instruction" gorm:"not null"` // e.g. "Shorten the summary"
+	Content     string    `json:"content" gorm:"type:text;not null"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// CurrentContent returns the resume content after the latest turn
+func (s *RefinementSession) CurrentContent() string {
+	if len(s.Turns) == 0 {
+		return s.BaseContent
+	}
+	return s.Turns[len(s.Turns)-1].Content
+}
+
+// CurrentTurn returns the number of the latest turn, 0 when no revision was made yet
+func (s *RefinementSession) CurrentTurn() int {
+	if len(s.Turns) == 0 {
+		return 0
+	}
+	return s.Turns[len(s.Turns)-1].Number
+}
+
+// ContentAt returns the resume content after a turn, the base content for turn 0
+func (s *RefinementSession) ContentAt(number int) string {
+	for _, turn := range s.Turns {
+		if turn.Number == number {
+			return turn.Content
+		}
+	}
+	return s.BaseContent
+}
@@ -19,6 +19,7 @@ type Experience struct {
 	Current     bool       `json:"current"`
 	Location    string     `json:"location"`
 	Description []string   `json:"description"`
+	Source      string     `json:"source,omitempty"` // Profile record the entry is based on, e.g. "WE-12"
 }
 
 // type Education struct {
@@ -57,20 +58,30 @@ type GenerateResumeResponse struct {
 	Suggestions   []string      `json:"suggestions"`
 }
 
+// Formats of the stored resume content
+const (
+	ResumeFormatMarkdown = "markdown" // Content is markdown text
+	ResumeFormatJSON     = "json"     // Content is a JSON encoded ResumeContent
+)
+
 // Resume represents a specific version of a user's resume
 type Resume struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	UserID      uint      `json:"userId" gorm:"not null"`
-	Name        string    `json:"name" gorm:"not null"`     // e.g., "Software Engineer - Google"
-	Description string    `json:"description"`              // Optional description
-	JobTitle    string    `json:"jobTitle"`                 // Target job title
-	Company     string    `json:"company"`                  // Target company
-	Content     string    `json:"content" gorm:"type:text"` // JSON content of the resume
-	IsDefault   bool      `json:"isDefault" gorm:"default:false"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          uint                 `json:"id" gorm:"primaryKey"`
+	UserID      uint                 `json:"userId" gorm:"not null"`
+	Name        string               `json:"name" gorm:"not null"`           // e.g., "Software Engineer - Google"
+	Description string               `json:"description"`                    // Optional description
+	JobTitle    string               `json:"jobTitle"`                       // Target job title
+	Company     string               `json:"company"`                        // Target company
+	Content     string               `json:"content" gorm:"type:text"`       // JSON content of the resume
+	Format      string               `json:"format" gorm:"default:markdown"` // ResumeFormatMarkdown or ResumeFormatJSON
+	IsDefault   bool                 `json:"isDefault" gorm:"default:false"`
+	Findings    VerificationFindings `json:"findings,omitempty" gorm:"type:jsonb"`   // Content not backed by the profile, for generated resumes
+	Provenance  Provenance           `json:"provenance,omitempty" gorm:"type:jsonb"` // Profile records behind each generated bullet point
+	CreatedAt   time.Time            `json:"createdAt"`
+	UpdatedAt   time.Time            `json:"updatedAt"`
 
-	User User `json:"-" gorm:"foreignKey:UserID"`
+	User   User          `json:"-" gorm:"foreignKey:UserID"`
+	Skills []ResumeSkill `json:"skills,omitempty" gorm:"foreignKey:ResumeID"`
 }
 
 // ResumeSkill represents skills included in a specific resume version
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Kinds of verification findings
+const (
+	FindingName    = "name"
+	FindingEmail   = "email"
+	FindingPhone   = "phone"
+	FindingCompany = "company"
+	FindingTitle   = "title"
+	FindingSchool  = "school"
+	FindingDegree  = "degree"
+	FindingDate    = "date"
+	FindingEntry   = "entry" // Heading or entry line of a markdown section that matches no profile record
+)
+
+// Actions taken on verification findings
+const (
+	FindingFlagged   = "flagged"   // Reported only
+	FindingStripped  = "stripped"  // Removed from the resume
+	FindingCorrected = "corrected" // Replaced with the value of the profile
+)
+
+// VerificationFinding is content of a generated resume that is not backed by the user's profile
+type VerificationFinding struct {
+	Kind     string `json:"kind"`
+	Value    string `json:"value"`              // Offending text
+	Expected string `json:"expected,omitempty"` // Profile value, for personal details
+	Location string `json:"location"`           // "line 12" in markdown, a field path such as "experience[1].company" in JSON
+	Action   string `json:"action"`
+}
+
+// VerificationFindings is stored as a JSON array
+type VerificationFindings []VerificationFinding
+
+// Value implements driver.Valuer
+func (f VerificationFindings) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (f *VerificationFindings) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	}
+	return errors.New("unsupported type for verification findings")
+}
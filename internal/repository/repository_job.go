@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrJobNotFound is returned when a generation job does not exist
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobLockLost is returned when a worker no longer holds the lock of its job, e.g.
+	// because the job was requeued as stale
+	ErrJobLockLost = errors.New("job lock lost")
+	// ErrJobQueueFull is returned when creating a job while the queue holds its maximum of jobs
+	ErrJobQueueFull = errors.New("job queue is full")
+	// ErrJobUserQueueFull is returned when creating a job for a user who has the maximum of
+	// queued jobs
+	ErrJobUserQueueFull = errors.New("job queue of the user is full")
+)
+
+// staleJobError is the error of jobs that went stale on their last attempt
+const staleJobError = "worker stopped responding"
+
+type JobRepository struct {
+	db interfaces.DB
+}
+
+func NewJobRepository(db interfaces.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// CreateJob inserts a new queued job unless maxQueued jobs, or maxUserQueued jobs of its
+// user, are queued already. The queue is counted and the job inserted under a table lock, so
+// that concurrent submissions cannot exceed the limits.
+func (r *JobRepository) CreateJob(ctx context.Context, job *models.GenerationJob, maxQueued, maxUserQueued int) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Conflicts with itself and with writes, so submissions wait for each other while reads
+	// continue; claims and cancellations only shorten the queue
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE generation_jobs IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+	var queued int64
+	err = tx.WithContext(ctx).
+		Model(&models.GenerationJob{}).
+		Where("status = ?", models.JobStatusQueued).
+		Count(&queued).Error
+	if err != nil {
+		return err
+	}
+	if queued >= int64(maxQueued) {
+		return ErrJobQueueFull
+	}
+	err = tx.WithContext(ctx).
+		Model(&models.GenerationJob{}).
+		Where("user_id = ? AND status = ?", job.UserID, models.JobStatusQueued).
+		Count(&queued).Error
+	if err != nil {
+		return err
+	}
+	if queued >= int64(maxUserQueued) {
+		return ErrJobUserQueueFull
+	}
+
+	now := time.Now()
+	job.Status = models.JobStatusQueued
+	job.RunAfter = now
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	if err := tx.Create(job); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetJob retrieves a job by ID
+func (r *JobRepository) GetJob(ctx context.Context, id string) (*models.GenerationJob, error) {
+	var job models.GenerationJob
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListQueuedJobs returns the jobs waiting for a worker, oldest first, without their
+// parameters and results
+func (r *JobRepository) ListQueuedJobs(ctx context.Context) ([]models.GenerationJob, error) {
+	var jobs []models.GenerationJob
+	err := r.db.WithContext(ctx).
+		Select("id", "user_id", "run_after", "created_at").
+		Where("status = ?", models.JobStatusQueued).
+		Order("run_after, created_at").
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// fairClaimOrder orders queued jobs so that workers serve users round-robin: users with the
+// fewest running jobs first, then the user whose jobs started least recently, then the
+// oldest job
+const fairClaimOrder = `(SELECT COUNT(*) FROM generation_jobs r WHERE r.user_id = generation_jobs.user_id AND r.status = 'running'),
+	(SELECT MAX(r.started_at) FROM generation_jobs r WHERE r.user_id = generation_jobs.user_id) NULLS FIRST,
+	run_after, created_at`
+
+// ClaimNextJob locks the next runnable queued job for a worker and marks it running. Users
+// are served round-robin (see fairClaimOrder), so one user submitting many generations
+// cannot starve the others. Concurrent workers skip jobs locked by others (FOR UPDATE SKIP
+// LOCKED). It returns nil when no job is runnable.
+func (r *JobRepository) ClaimNextJob(ctx context.Context, workerID string) (*models.GenerationJob, error) {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var job models.GenerationJob
+	err = tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND run_after <= ?", models.JobStatusQueued, time.Now()).
+		Order(fairClaimOrder).
+		Limit(1).
+		Find(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	if job.ID == "" {
+		return nil, nil
+	}
+
+	now := time.Now()
+	job.Status = models.JobStatusRunning
+	job.Attempts++
+	job.LockedBy = workerID
+	job.LockedAt = &now
+	job.StartedAt = &now
+	job.UpdatedAt = now
+	err = tx.Model(&models.GenerationJob{}).
+		Where("id = ?", job.ID).
+		Updates(map[string]interface{}{
+			"status":     job.Status,
+			"attempts":   job.Attempts,
+			"locked_by":  job.LockedBy,
+			"locked_at":  job.LockedAt,
+			"started_at": job.StartedAt,
+			"updated_at": job.UpdatedAt,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// TouchJob refreshes the lock of a running job so that it is not considered stale. It returns
+// ErrJobLockLost when the worker no longer holds the lock.
+func (r *JobRepository) TouchJob(ctx context.Context, id, workerID string) error {
+	result := r.runningJob(ctx, id, workerID).Update("locked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobLockLost
+	}
+	return nil
+}
+
+// CompleteJob marks a running job succeeded with the saved resume and its content
+func (r *JobRepository) CompleteJob(ctx context.Context, id, workerID string, resumeID uint, result string) error {
+	return r.finishJob(ctx, id, workerID, map[string]interface{}{
+		"status":    models.JobStatusSucceeded,
+		"resume_id": resumeID,
+		"result":    result,
+		"error":     "",
+	})
+}
+
+// FailJob marks a running job failed, or queues it again at retryAt when retryAt is not nil
+func (r *JobRepository) FailJob(ctx context.Context, id, workerID, message string, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"status": models.JobStatusFailed,
+		"error":  message,
+	}
+	if retryAt != nil {
+		updates["status"] = models.JobStatusQueued
+		updates["run_after"] = *retryAt
+	}
+	return r.finishJob(ctx, id, workerID, updates)
+}
+
+// CancelRunningJob marks a running job canceled
+func (r *JobRepository) CancelRunningJob(ctx context.Context, id, workerID string) error {
+	return r.finishJob(ctx, id, workerID, map[string]interface{}{
+		"status": models.JobStatusCanceled,
+		"error":  "job canceled",
+	})
+}
+
+// RequeueJob puts an interrupted running job back in the queue without counting the attempt
+func (r *JobRepository) RequeueJob(ctx context.Context, id, workerID string) error {
+	return r.finishJob(ctx, id, workerID, map[string]interface{}{
+		"status":    models.JobStatusQueued,
+		"attempts":  gorm.Expr("GREATEST(attempts - 1, 0)"),
+		"run_after": time.Now(),
+	})
+}
+
+// CancelQueuedJob cancels a job that no worker picked up yet. It reports whether the job was canceled.
+func (r *JobRepository) CancelQueuedJob(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.GenerationJob{}).
+		Where("id = ? AND status = ?", id, models.JobStatusQueued).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusCanceled,
+			"error":      "job canceled",
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RequeueStaleJobs queues again running jobs whose worker stopped refreshing the lock
+// before staleBefore, e.g. because the process crashed. Stale jobs that used up their
+// attempts are marked failed instead, so that a job crashing its worker is not retried
+// forever; the attempt was counted when the job was claimed. It returns the number of jobs
+// requeued and failed.
+func (r *JobRepository) RequeueStaleJobs(ctx context.Context, staleBefore time.Time) (requeued, failed int64, err error) {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result := tx.WithContext(ctx).
+		Model(&models.GenerationJob{}).
+		Where("status = ? AND locked_at < ? AND attempts >= max_attempts", models.JobStatusRunning, staleBefore).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusFailed,
+			"error":      staleJobError,
+			"locked_by":  nil,
+			"locked_at":  nil,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return 0, 0, result.Error
+	}
+	failed = result.RowsAffected
+
+	result = tx.WithContext(ctx).
+		Model(&models.GenerationJob{}).
+		Where("status = ? AND locked_at < ?", models.JobStatusRunning, staleBefore).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusQueued,
+			"locked_by":  nil,
+			"locked_at":  nil,
+			"run_after":  now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return 0, 0, result.Error
+	}
+	requeued = result.RowsAffected
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return requeued, failed, nil
+}
+
+// runningJob scopes a query to a job running under the given worker, so that a worker
+// whose job was requeued as stale cannot overwrite the job's new state
+func (r *JobRepository) runningJob(ctx context.Context, id, workerID string) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Model(&models.GenerationJob{}).
+		Where("id = ? AND status = ? AND locked_by = ?", id, models.JobStatusRunning, workerID)
+}
+
+// finishJob releases the lock of a running job and applies updates
+func (r *JobRepository) finishJob(ctx context.Context, id, workerID string, updates map[string]interface{}) error {
+	updates["locked_by"] = nil
+	updates["locked_at"] = nil
+	updates["updated_at"] = time.Now()
+	result := r.runningJob(ctx, id, workerID).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type KeywordRepository struct {
+	db interfaces.DB
+}
+
+func NewKeywordRepository(db interfaces.DB) *KeywordRepository {
+	return &KeywordRepository{db: db}
+}
+
+// UpsertDocument stores a document and its keyword vector, replacing an earlier version with
+// the same source, and updates the document frequencies and corpus totals incrementally
+func (r *KeywordRepository) UpsertDocument(ctx context.Context, doc *models.KeywordVector) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var existing models.KeywordVector
+	err = tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("source_type = ? AND source_id = ?", doc.SourceType, doc.SourceID).
+		Limit(1).
+		Find(&existing).Error
+	if err != nil {
+		return err
+	}
+
+	if existing.ID != 0 {
+		if err := adjustStatistics(ctx, tx, &existing, -1); err != nil {
+			return err
+		}
+		doc.ID = existing.ID
+		doc.CreatedAt = existing.CreatedAt
+		if err := tx.WithContext(ctx).Model(&existing).Updates(map[string]interface{}{
+			"text":             doc.Text,
+			"keywords":         doc.Keywords,
+			"vector_embedding": doc.Embedding,
+			"embedding_model":  doc.EmbeddingModel,
+		}).Error; err != nil {
+			return err
+		}
+	} else {
+		doc.CreatedAt = time.Now()
+		// A concurrent ingestion of the same document wins; its statistics are already counted
+		result := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(doc)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+	}
+
+	if err := adjustStatistics(ctx, tx, doc, 1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// adjustStatistics adds (sign 1) or removes (sign -1) a job description from the document
+// frequencies and corpus totals. Other documents do not count towards the statistics.
+func adjustStatistics(ctx context.Context, tx interfaces.Tx, doc *models.KeywordVector, sign int) error {
+	if doc.SourceType != models.KeywordSourceJob {
+		return nil
+	}
+
+	frequencies := make([]models.KeywordDocumentFrequency, 0, len(doc.Keywords))
+	for _, k := range doc.Keywords {
+		frequencies = append(frequencies, models.KeywordDocumentFrequency{Term: k.Term, DocumentCount: sign})
+	}
+	if len(frequencies) > 0 {
+		err := tx.WithContext(ctx).
+			Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "term"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"document_count": gorm.Expr("keyword_document_frequencies.document_count + excluded.document_count"),
+				}),
+			}).
+			CreateInBatches(frequencies, 500).Error
+		if err != nil {
+			return err
+		}
+		if sign < 0 {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM keyword_document_frequencies WHERE document_count <= 0"); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := tx.ExecContext(ctx,
+		"UPDATE keyword_corpus_stats SET document_count = document_count + ?, term_count = term_count + ?, updated_at = ? WHERE id = 1",
+		sign, sign*doc.TermCount(), time.Now(),
+	)
+	return err
+}
+
+// GetDocuments returns the stored documents of the given sources
+func (r *KeywordRepository) GetDocuments(ctx context.Context, sourceType string, sourceIDs []string) ([]models.KeywordVector, error) {
+	var docs []models.KeywordVector
+	if len(sourceIDs) == 0 {
+		return docs, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("source_type = ? AND source_id IN ?", sourceType, sourceIDs).
+		Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// ListDocuments returns up to limit documents of a source type whose IDs are above afterID,
+// in ID order, for paging through the corpus
+func (r *KeywordRepository) ListDocuments(ctx context.Context, sourceType string, afterID, limit int) ([]models.KeywordVector, error) {
+	var docs []models.KeywordVector
+	err := r.db.WithContext(ctx).
+		Where("source_type = ? AND id > ?", sourceType, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// UpdateKeywords replaces the keywords of a document. The statistics are not adjusted, so
+// RecomputeStatistics has to follow.
+func (r *KeywordRepository) UpdateKeywords(ctx context.Context, id int, keywords models.KeywordList) error {
+	return r.db.WithContext(ctx).
+		Model(&models.KeywordVector{}).
+		Where("id = ?", id).
+		Update("keywords", keywords).Error
+}
+
+// DeleteDocument removes a document from the corpus and its statistics
+func (r *KeywordRepository) DeleteDocument(ctx context.Context, sourceType, sourceID string) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var doc models.KeywordVector
+	err = tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+		Limit(1).
+		Find(&doc).Error
+	if err != nil || doc.ID == 0 {
+		return err
+	}
+
+	if err := tx.WithContext(ctx).Delete(&doc).Error; err != nil {
+		return err
+	}
+	if err := adjustStatistics(ctx, tx, &doc, -1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindNearest returns the documents of a source type whose vectors, produced by the same
+// embedder, are closest to embedding by cosine distance, most similar first. When sourceIDs
+// is not nil the search is limited to those sources. The filter is applied exactly, so that
+// approximate indexes cannot drop matching documents. Zero vectors, whose cosine distance is
+// NaN, are skipped.
+func (r *KeywordRepository) FindNearest(ctx context.Context, embedding models.Vector, embeddingModel, sourceType string, sourceIDs []string, limit int) ([]models.KeywordVectorResult, error) {
+	results := []models.KeywordVectorResult{}
+	if sourceIDs != nil && len(sourceIDs) == 0 {
+		return results, nil
+	}
+
+	query := r.db.WithContext(ctx).
+		Model(&models.KeywordVector{}).
+		Select("id, source_type, source_id, keywords, 1 - (vector_embedding <=> ?::vector) AS similarity", embedding).
+		Where("source_type = ? AND embedding_model = ? AND vector_embedding IS NOT NULL AND vector_norm(vector_embedding) > 0", sourceType, embeddingModel)
+	if sourceIDs != nil {
+		query = query.Where("source_id IN ?", sourceIDs)
+	}
+	err := query.
+		Order(clause.Expr{SQL: "vector_embedding <=> ?::vector", Vars: []interface{}{embedding}}).
+		Limit(limit).
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetEmbeddingDimensions returns the declared dimensions of the vector_embedding column, or
+// -1 when the column does not declare any. pgvector stores them as the type modifier.
+func (r *KeywordRepository) GetEmbeddingDimensions(ctx context.Context) (int, error) {
+	var dimensions int
+	result := r.db.WithContext(ctx).
+		Raw("SELECT atttypmod FROM pg_attribute WHERE attrelid = 'keyword_vectors'::regclass AND attname = 'vector_embedding' AND NOT attisdropped").
+		Scan(&dimensions)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, errors.New("column keyword_vectors.vector_embedding does not exist")
+	}
+	return dimensions, nil
+}
+
+// GetDocumentFrequencies returns the number of job descriptions each of the terms occurs in.
+// Terms that occur in none are missing from the result.
+func (r *KeywordRepository) GetDocumentFrequencies(ctx context.Context, terms []string) (map[string]int, error) {
+	frequencies := make(map[string]int, len(terms))
+	if len(terms) == 0 {
+		return frequencies, nil
+	}
+
+	var rows []models.KeywordDocumentFrequency
+	if err := r.db.WithContext(ctx).Where("term IN ?", terms).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		frequencies[row.Term] = row.DocumentCount
+	}
+	return frequencies, nil
+}
+
+// GetCorpusStats returns the totals of the keyword corpus
+func (r *KeywordRepository) GetCorpusStats(ctx context.Context) (*models.KeywordCorpusStats, error) {
+	var stats models.KeywordCorpusStats
+	if err := r.db.WithContext(ctx).Where("id = 1").Limit(1).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// RecomputeStatistics rebuilds the document frequencies and corpus totals from the job
+// descriptions in the corpus, e.g. after documents were changed outside the application
+func (r *KeywordRepository) RecomputeStatistics(ctx context.Context) (*models.KeywordCorpusStats, error) {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Block incremental updates while the statistics are rebuilt
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE keyword_document_frequencies, keyword_corpus_stats IN EXCLUSIVE MODE"); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM keyword_document_frequencies"); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO keyword_document_frequencies (term, document_count)
+		SELECT COALESCE(k->>'term', k->>'word'), COUNT(DISTINCT v.id)
+		FROM keyword_vectors v, jsonb_array_elements(v.keywords) k
+		WHERE v.source_type = ?
+		GROUP BY COALESCE(k->>'term', k->>'word')`,
+		models.KeywordSourceJob,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO keyword_corpus_stats (id, document_count, term_count, updated_at)
+		SELECT 1,
+			(SELECT COUNT(*) FROM keyword_vectors WHERE source_type = ?),
+			(SELECT COALESCE(SUM((k->>'count')::bigint), 0) FROM keyword_vectors v, jsonb_array_elements(v.keywords) k WHERE v.source_type = ?),
+			?
+		ON CONFLICT (id) DO UPDATE SET
+			document_count = excluded.document_count,
+			term_count = excluded.term_count,
+			updated_at = excluded.updated_at`,
+		models.KeywordSourceJob, models.KeywordSourceJob, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats models.KeywordCorpusStats
+	if err := tx.WithContext(ctx).Where("id = 1").First(&stats).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
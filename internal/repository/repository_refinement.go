@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrSessionNotFound is returned when a refinement session does not exist or belongs to another user
+	ErrSessionNotFound = errors.New("refinement session not found")
+	// ErrResumeChanged is returned when the resume of a session was changed since the session's
+	// latest turn, by an edit or a concurrent turn, so that writing the session's content would
+	// discard that change
+	ErrResumeChanged = errors.New("resume was changed since the latest turn of the session")
+)
+
+// ResumeRevision is the content a refinement session writes to its resume, with the findings
+// and provenance that apply to it
+type ResumeRevision struct {
+	Content    string
+	Findings   models.VerificationFindings
+	Provenance models.Provenance
+}
+
+type RefinementRepository struct {
+	db interfaces.DB
+}
+
+func NewRefinementRepository(db interfaces.DB) *RefinementRepository {
+	return &RefinementRepository{db: db}
+}
+
+// CreateSession creates a new refinement session
+func (r *RefinementRepository) CreateSession(ctx context.Context, session *models.RefinementSession) error {
+	now := time.Now()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	return r.db.WithContext(ctx).Omit("Turns").Create(session).Error
+}
+
+// GetSession retrieves a refinement session of a user with its turns in order
+func (r *RefinementRepository) GetSession(ctx context.Context, userID, sessionID uint) (*models.RefinementSession, error) {
+	var session models.RefinementSession
+	err := r.db.WithContext(ctx).
+		Preload("Turns", func(db *gorm.DB) *gorm.DB {
+			return db.Order("number ASC")
+		}).
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetSessionsByResumeID retrieves the refinement sessions of a resume version, newest first
+func (r *RefinementRepository) GetSessionsByResumeID(ctx context.Context, userID, resumeID uint) ([]models.RefinementSession, error) {
+	var sessions []models.RefinementSession
+	err := r.db.WithContext(ctx).
+		Where("resume_id = ? AND user_id = ?", resumeID, userID).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// AddTurn saves a turn following the latest turn of the session as loaded, and writes the
+// revision to the session's resume. It returns ErrResumeChanged when another turn was added
+// or the resume was changed since the session was loaded.
+func (r *RefinementRepository) AddTurn(ctx context.Context, session *models.RefinementSession, turn *models.RefinementTurn, revision *ResumeRevision) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.lockSessionTx(ctx, tx, session); err != nil {
+		return err
+	}
+
+	turn.SessionID = session.ID
+	turn.Number = session.CurrentTurn() + 1
+	turn.CreatedAt = time.Now()
+	if err := tx.Create(turn); err != nil {
+		return err
+	}
+
+	if err := r.updateContentTx(tx, session, revision, turn.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RollbackSession deletes the turns after the given turn number and writes the revision, the
+// content of that turn, to the session's resume. It returns ErrResumeChanged like AddTurn.
+func (r *RefinementRepository) RollbackSession(ctx context.Context, session *models.RefinementSession, number int, revision *ResumeRevision) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.lockSessionTx(ctx, tx, session); err != nil {
+		return err
+	}
+
+	err = tx.Where("session_id = ? AND number > ?", session.ID, number).
+		Delete(&models.RefinementTurn{}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := r.updateContentTx(tx, session, revision, time.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteSession deletes a refinement session of a user; the resume keeps its current content
+func (r *RefinementRepository) DeleteSession(ctx context.Context, userID, sessionID uint) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		Delete(&models.RefinementSession{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// lockSessionTx locks a session until the transaction ends, so that turns are numbered and
+// written one at a time, and returns ErrResumeChanged when turns were added or removed since
+// the session was loaded
+func (r *RefinementRepository) lockSessionTx(ctx context.Context, tx interfaces.Tx, session *models.RefinementSession) error {
+	var locked models.RefinementSession
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", session.ID).
+		First(&locked).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	var latest int
+	err = tx.WithContext(ctx).
+		Model(&models.RefinementTurn{}).
+		Select("COALESCE(MAX(number), 0)").
+		Where("session_id = ?", session.ID).
+		Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+	if latest != session.CurrentTurn() {
+		return ErrResumeChanged
+	}
+	return nil
+}
+
+// updateContentTx writes a revision to the session's resume, provided the resume still has
+// the content of the session's latest turn, and touches the session
+func (r *RefinementRepository) updateContentTx(tx interfaces.Tx, session *models.RefinementSession, revision *ResumeRevision, now time.Time) error {
+	result := tx.Model(&models.Resume{}).
+		Where("id = ? AND user_id = ? AND content = ?", session.ResumeID, session.UserID, session.CurrentContent()).
+		Updates(map[string]interface{}{
+			"content":    revision.Content,
+			"findings":   revision.Findings,
+			"provenance": revision.Provenance,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		err := tx.Model(&models.Resume{}).
+			Where("id = ? AND user_id = ?", session.ResumeID, session.UserID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrResumeNotFound
+		}
+		return ErrResumeChanged
+	}
+
+	return tx.Model(&models.RefinementSession{}).
+		Where("id = ?", session.ID).
+		Update("updated_at", now).Error
+}
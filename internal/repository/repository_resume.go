@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrResumeNotFound is returned when a resume does not exist or belongs to another user
+	ErrResumeNotFound = errors.New("resume not found")
+	// ErrResumeUserNotFound is returned when saving a resume for a user that does not exist
+	ErrResumeUserNotFound = errors.New("user not found")
+	// ErrResumeSkillNotFound is returned when a resume references a skill that does not exist
+	ErrResumeSkillNotFound = errors.New("skill not found")
+)
+
+// Foreign keys of the resume tables, named by PostgreSQL's defaults
+const (
+	resumeUserForeignKey  = "resumes_user_id_fkey"
+	resumeSkillForeignKey = "resume_skills_skill_id_fkey"
+)
+
+// foreignKeyViolation is the SQLSTATE of a violated foreign key constraint
+const foreignKeyViolation = "23503"
+
+// translateResumeError maps violations of the foreign keys of resumes to sentinel errors
+func translateResumeError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != foreignKeyViolation {
+		return err
+	}
+	switch pgErr.ConstraintName {
+	case resumeUserForeignKey:
+		return ErrResumeUserNotFound
+	case resumeSkillForeignKey:
+		return ErrResumeSkillNotFound
+	}
+	return err
+}
+
+type ResumeRepository struct {
+	db interfaces.DB
+}
+
+func NewResumeRepository(db interfaces.DB) *ResumeRepository {
+	return &ResumeRepository{db: db}
+}
+
+// CreateResume creates a new resume version together with its skills
+func (r *ResumeRepository) CreateResume(ctx context.Context, resume *models.Resume) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	resume.CreatedAt = now
+	resume.UpdatedAt = now
+	if resume.Format == "" {
+		resume.Format = models.ResumeFormatMarkdown
+	}
+
+	if resume.IsDefault {
+		if err := r.clearDefaultTx(tx, resume.UserID, 0); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(resume).Omit("User", "Skills").Create(resume).Error; err != nil {
+		return translateResumeError(err)
+	}
+
+	if err := r.createResumeSkillsTx(tx, resume.ID, resume.Skills); err != nil {
+		return translateResumeError(err)
+	}
+
+	return tx.Commit()
+}
+
+// GetResumesByUserID retrieves all resume versions of a user, newest first
+func (r *ResumeRepository) GetResumesByUserID(ctx context.Context, userID uint) ([]models.Resume, error) {
+	var resumes []models.Resume
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&resumes).Error
+	if err != nil {
+		return nil, err
+	}
+	return resumes, nil
+}
+
+// GetResumeByID retrieves a single resume of a user with its skills
+func (r *ResumeRepository) GetResumeByID(ctx context.Context, userID, resumeID uint) (*models.Resume, error) {
+	var resume models.Resume
+	err := r.db.WithContext(ctx).
+		Preload("Skills", func(db *gorm.DB) *gorm.DB {
+			return db.Order(`"order" ASC`)
+		}).
+		Preload("Skills.Skill").
+		Where("id = ? AND user_id = ?", resumeID, userID).
+		First(&resume).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResumeNotFound
+		}
+		return nil, err
+	}
+	return &resume, nil
+}
+
+// UpdateResume updates a resume version. Skills are replaced when resume.Skills is not nil.
+func (r *ResumeRepository) UpdateResume(ctx context.Context, resume *models.Resume) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if resume.IsDefault {
+		if err := r.clearDefaultTx(tx, resume.UserID, resume.ID); err != nil {
+			return err
+		}
+	}
+
+	resume.UpdatedAt = time.Now()
+	if resume.Format == "" {
+		resume.Format = models.ResumeFormatMarkdown
+	}
+	result := tx.Model(&models.Resume{}).
+		Where("id = ? AND user_id = ?", resume.ID, resume.UserID).
+		Updates(map[string]interface{}{
+			"name":        resume.Name,
+			"description": resume.Description,
+			"job_title":   resume.JobTitle,
+			"company":     resume.Company,
+			"content":     resume.Content,
+			"format":      resume.Format,
+			"is_default":  resume.IsDefault,
+			"updated_at":  resume.UpdatedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrResumeNotFound
+	}
+
+	if resume.Skills != nil {
+		if err := tx.Where("resume_id = ?", resume.ID).Delete(&models.ResumeSkill{}).Error; err != nil {
+			return err
+		}
+		if err := r.createResumeSkillsTx(tx, resume.ID, resume.Skills); err != nil {
+			return translateResumeError(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteResume deletes a resume version of a user
+func (r *ResumeRepository) DeleteResume(ctx context.Context, userID, resumeID uint) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", resumeID, userID).
+		Delete(&models.Resume{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrResumeNotFound
+	}
+	return nil
+}
+
+// clearDefaultTx unsets the default flag on all resumes of a user except the given one
+func (r *ResumeRepository) clearDefaultTx(tx interfaces.Tx, userID, exceptID uint) error {
+	return tx.Model(&models.Resume{}).
+		Where("user_id = ? AND id <> ? AND is_default", userID, exceptID).
+		Update("is_default", false).Error
+}
+
+// createResumeSkillsTx links skills to a resume within a transaction
+func (r *ResumeRepository) createResumeSkillsTx(tx interfaces.Tx, resumeID uint, skills []models.ResumeSkill) error {
+	if len(skills) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range skills {
+		skills[i].ID = 0
+		skills[i].ResumeID = resumeID
+		skills[i].CreatedAt = now
+		skills[i].UpdatedAt = now
+	}
+	return tx.Model(&models.ResumeSkill{}).Omit("Resume", "Skill").CreateInBatches(skills, 100).Error
+}
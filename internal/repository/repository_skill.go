@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SkillRepository struct {
+	db interfaces.DB
+}
+
+func NewSkillRepository(db interfaces.DB) *SkillRepository {
+	return &SkillRepository{db: db}
+}
+
+// UpsertSkills creates the skills that do not exist yet. Existing skills keep their category
+// unless they have none.
+func (r *SkillRepository) UpsertSkills(ctx context.Context, skills []models.Skill) error {
+	if len(skills) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "name"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"category": gorm.Expr("COALESCE(NULLIF(skills.category, ''), excluded.category)"),
+			}),
+		}).
+		Create(&skills).Error
+}
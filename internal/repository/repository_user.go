@@ -7,6 +7,7 @@ import (
 
 	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
 	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"gorm.io/gorm"
 )
 
 type UserRepository struct {
@@ -74,6 +75,33 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id uint) (*models.User
 	return &user, nil
 }
 
+// GetUserWithDetails retrieves a user by ID with their work experience and education
+func (r *UserRepository) GetUserWithDetails(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+
+	// Use a single query with proper indexing
+	err := r.db.WithContext(ctx).
+		Select("users.id, users.full_name, users.email, users.phone, users.location, users.title, users.summary").
+		Joins("LEFT JOIN work_experiences ON users.id = work_experiences.user_id").
+		Joins("LEFT JOIN educations ON users.id = educations.user_id").
+		Preload("WorkExperience", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, user_id, company, title, location, start_date, end_date, is_current, description").
+				Order("start_date DESC")
+		}).
+		Preload("Education", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id, user_id, school, degree, field, location, start_date, end_date, is_current, description").
+				Order("start_date DESC")
+		}).
+		Where("users.id = ?", id).
+		First(&user).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 // GetUserByEmail retrieves a user by their email
 func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
@@ -0,0 +1,228 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repository"
+)
+
+// Jobs is an in-memory JobRepository. Claims return the runnable job queued first rather
+// than serving users round-robin.
+type Jobs struct {
+	mu   sync.Mutex
+	jobs map[string]*models.GenerationJob
+}
+
+// NewJobs returns an empty job queue
+func NewJobs() *Jobs {
+	return &Jobs{jobs: make(map[string]*models.GenerationJob)}
+}
+
+// CreateJob queues a job unless maxQueued jobs, or maxUserQueued jobs of its user, are
+// queued already
+func (r *Jobs) CreateJob(ctx context.Context, job *models.GenerationJob, maxQueued, maxUserQueued int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queued, userQueued := 0, 0
+	for _, other := range r.jobs {
+		if other.Status == models.JobStatusQueued {
+			queued++
+			if other.UserID == job.UserID {
+				userQueued++
+			}
+		}
+	}
+	if queued >= maxQueued {
+		return repository.ErrJobQueueFull
+	}
+	if userQueued >= maxUserQueued {
+		return repository.ErrJobUserQueueFull
+	}
+
+	now := time.Now()
+	job.Status = models.JobStatusQueued
+	job.RunAfter = now
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	stored := *job
+	r.jobs[job.ID] = &stored
+	return nil
+}
+
+// GetJob returns a copy of a job
+func (r *Jobs) GetJob(ctx context.Context, id string) (*models.GenerationJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, repository.ErrJobNotFound
+	}
+	found := *job
+	return &found, nil
+}
+
+// ListQueuedJobs returns the queued jobs, oldest first
+func (r *Jobs) ListQueuedJobs(ctx context.Context) ([]models.GenerationJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.queued(time.Time{}), nil
+}
+
+// ClaimNextJob marks the oldest runnable queued job running under workerID, or returns nil
+func (r *Jobs) ClaimNextJob(ctx context.Context, workerID string) (*models.GenerationJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runnable := r.queued(time.Now())
+	if len(runnable) == 0 {
+		return nil, nil
+	}
+
+	job := r.jobs[runnable[0].ID]
+	now := time.Now()
+	job.Status = models.JobStatusRunning
+	job.Attempts++
+	job.LockedBy = workerID
+	job.LockedAt = &now
+	job.StartedAt = &now
+	job.UpdatedAt = now
+	claimed := *job
+	return &claimed, nil
+}
+
+// TouchJob refreshes the lock of a running job, or returns ErrJobLockLost
+func (r *Jobs) TouchJob(ctx context.Context, id, workerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.running(id, workerID)
+	if job == nil {
+		return repository.ErrJobLockLost
+	}
+	now := time.Now()
+	job.LockedAt = &now
+	return nil
+}
+
+// CompleteJob marks a running job succeeded
+func (r *Jobs) CompleteJob(ctx context.Context, id, workerID string, resumeID uint, result string) error {
+	return r.finish(id, workerID, func(job *models.GenerationJob) {
+		job.Status = models.JobStatusSucceeded
+		job.ResumeID = &resumeID
+		job.Result = result
+		job.Error = ""
+	})
+}
+
+// FailJob marks a running job failed, or queues it again at retryAt when retryAt is not nil
+func (r *Jobs) FailJob(ctx context.Context, id, workerID, message string, retryAt *time.Time) error {
+	return r.finish(id, workerID, func(job *models.GenerationJob) {
+		job.Status = models.JobStatusFailed
+		job.Error = message
+		if retryAt != nil {
+			job.Status = models.JobStatusQueued
+			job.RunAfter = *retryAt
+		}
+	})
+}
+
+// CancelRunningJob marks a running job canceled
+func (r *Jobs) CancelRunningJob(ctx context.Context, id, workerID string) error {
+	return r.finish(id, workerID, func(job *models.GenerationJob) {
+		job.Status = models.JobStatusCanceled
+		job.Error = "job canceled"
+	})
+}
+
+// RequeueJob puts a running job back in the queue without counting the attempt
+func (r *Jobs) RequeueJob(ctx context.Context, id, workerID string) error {
+	return r.finish(id, workerID, func(job *models.GenerationJob) {
+		job.Status = models.JobStatusQueued
+		job.Attempts = max(job.Attempts-1, 0)
+		job.RunAfter = time.Now()
+	})
+}
+
+// CancelQueuedJob cancels a queued job and reports whether it was queued
+func (r *Jobs) CancelQueuedJob(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok || job.Status != models.JobStatusQueued {
+		return false, nil
+	}
+	job.Status = models.JobStatusCanceled
+	job.Error = "job canceled"
+	job.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// RequeueStaleJobs queues again running jobs locked before staleBefore, or fails those
+// that used up their attempts
+func (r *Jobs) RequeueStaleJobs(ctx context.Context, staleBefore time.Time) (requeued, failed int64, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, job := range r.jobs {
+		if job.Status != models.JobStatusRunning || job.LockedAt == nil || !job.LockedAt.Before(staleBefore) {
+			continue
+		}
+		if job.Attempts >= job.MaxAttempts {
+			job.Status = models.JobStatusFailed
+			job.Error = "worker stopped responding"
+			failed++
+		} else {
+			job.Status = models.JobStatusQueued
+			job.RunAfter = now
+			requeued++
+		}
+		job.LockedBy = ""
+		job.LockedAt = nil
+		job.UpdatedAt = now
+	}
+	return requeued, failed, nil
+}
+
+// queued returns copies of the queued jobs runnable at before, or of all queued jobs when
+// before is zero, oldest first
+func (r *Jobs) queued(before time.Time) []models.GenerationJob {
+	var jobs []models.GenerationJob
+	for _, job := range r.jobs {
+		if job.Status == models.JobStatusQueued && (before.IsZero() || !job.RunAfter.After(before)) {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].RunAfter.Equal(jobs[j].RunAfter) {
+			return jobs[i].RunAfter.Before(jobs[j].RunAfter)
+		}
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// running returns a job running under workerID, or nil
+func (r *Jobs) running(id, workerID string) *models.GenerationJob {
+	job, ok := r.jobs[id]
+	if !ok || job.Status != models.JobStatusRunning || job.LockedBy != workerID {
+		return nil
+	}
+	return job
+}
+
+// finish releases the lock of a running job and applies update, or returns ErrJobNotFound
+func (r *Jobs) finish(id, workerID string, update func(*models.GenerationJob)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.running(id, workerID)
+	if job == nil {
+		return repository.ErrJobNotFound
+	}
+	update(job)
+	job.LockedBy = ""
+	job.LockedAt = nil
+	job.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,231 @@
+package repotest
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// Keywords is an in-memory KeywordRepository. The corpus statistics are computed from the
+// stored job descriptions whenever they are read.
+type Keywords struct {
+	mu     sync.Mutex
+	docs   []*models.KeywordVector
+	nextID int
+}
+
+// NewKeywords returns an empty corpus
+func NewKeywords() *Keywords {
+	return &Keywords{}
+}
+
+// UpsertDocument stores a document, replacing an earlier version with the same source
+func (r *Keywords) UpsertDocument(ctx context.Context, doc *models.KeywordVector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *doc
+	for i, existing := range r.docs {
+		if existing.SourceType == doc.SourceType && existing.SourceID == doc.SourceID {
+			stored.ID = existing.ID
+			stored.CreatedAt = existing.CreatedAt
+			r.docs[i] = &stored
+			doc.ID, doc.CreatedAt = stored.ID, stored.CreatedAt
+			return nil
+		}
+	}
+	r.nextID++
+	stored.ID = r.nextID
+	stored.CreatedAt = time.Now()
+	r.docs = append(r.docs, &stored)
+	doc.ID, doc.CreatedAt = stored.ID, stored.CreatedAt
+	return nil
+}
+
+// GetDocuments returns the stored documents of the given sources
+func (r *Keywords) GetDocuments(ctx context.Context, sourceType string, sourceIDs []string) ([]models.KeywordVector, error) {
+	ids := make(map[string]bool, len(sourceIDs))
+	for _, id := range sourceIDs {
+		ids[id] = true
+	}
+	var docs []models.KeywordVector
+	for _, doc := range r.documents(sourceType) {
+		if ids[doc.SourceID] {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// ListDocuments returns up to limit documents of a source type whose IDs are above afterID
+func (r *Keywords) ListDocuments(ctx context.Context, sourceType string, afterID, limit int) ([]models.KeywordVector, error) {
+	var docs []models.KeywordVector
+	for _, doc := range r.documents(sourceType) {
+		if doc.ID > afterID && len(docs) < limit {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// UpdateKeywords replaces the keywords of a document
+func (r *Keywords) UpdateKeywords(ctx context.Context, id int, keywords models.KeywordList) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, doc := range r.docs {
+		if doc.ID == id {
+			doc.Keywords = keywords
+		}
+	}
+	return nil
+}
+
+// DeleteDocument removes a document
+func (r *Keywords) DeleteDocument(ctx context.Context, sourceType, sourceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, doc := range r.docs {
+		if doc.SourceType == sourceType && doc.SourceID == sourceID {
+			r.docs = append(r.docs[:i], r.docs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// FindNearest returns the documents of a source type embedded by embeddingModel that are
+// closest to embedding by cosine similarity, most similar first
+func (r *Keywords) FindNearest(ctx context.Context, embedding models.Vector, embeddingModel, sourceType string, sourceIDs []string, limit int) ([]models.KeywordVectorResult, error) {
+	var ids map[string]bool
+	if sourceIDs != nil {
+		ids = make(map[string]bool, len(sourceIDs))
+		for _, id := range sourceIDs {
+			ids[id] = true
+		}
+	}
+
+	results := []models.KeywordVectorResult{}
+	for _, doc := range r.documents(sourceType) {
+		if doc.EmbeddingModel != embeddingModel || ids != nil && !ids[doc.SourceID] {
+			continue
+		}
+		similarity, ok := cosine(embedding, doc.Embedding)
+		if !ok {
+			continue
+		}
+		results = append(results, models.KeywordVectorResult{
+			ID:         doc.ID,
+			SourceType: doc.SourceType,
+			SourceID:   doc.SourceID,
+			Keywords:   doc.Keywords,
+			Similarity: similarity,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Similarity > results[j].Similarity })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// GetEmbeddingDimensions reports that the embeddings may have any dimensions
+func (r *Keywords) GetEmbeddingDimensions(ctx context.Context) (int, error) {
+	return -1, nil
+}
+
+// GetDocumentFrequencies returns the number of job descriptions each of the terms occurs in
+func (r *Keywords) GetDocumentFrequencies(ctx context.Context, terms []string) (map[string]int, error) {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+	frequencies := make(map[string]int, len(terms))
+	for _, doc := range r.documents(models.KeywordSourceJob) {
+		for _, k := range doc.Keywords {
+			if wanted[k.Term] {
+				frequencies[k.Term]++
+			}
+		}
+	}
+	return frequencies, nil
+}
+
+// GetCorpusStats returns the totals of the job descriptions
+func (r *Keywords) GetCorpusStats(ctx context.Context) (*models.KeywordCorpusStats, error) {
+	stats := &models.KeywordCorpusStats{ID: 1, UpdatedAt: time.Now()}
+	for _, doc := range r.documents(models.KeywordSourceJob) {
+		stats.DocumentCount++
+		stats.TermCount += int64(doc.TermCount())
+	}
+	return stats, nil
+}
+
+// RecomputeStatistics returns the totals of the job descriptions, which are never stale
+func (r *Keywords) RecomputeStatistics(ctx context.Context) (*models.KeywordCorpusStats, error) {
+	return r.GetCorpusStats(ctx)
+}
+
+// documents returns copies of the documents of a source type in ID order
+func (r *Keywords) documents(sourceType string) []models.KeywordVector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var docs []models.KeywordVector
+	for _, doc := range r.docs {
+		if doc.SourceType == sourceType {
+			docs = append(docs, *doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs
+}
+
+// cosine returns the cosine similarity of two vectors; ok is false when either is zero or
+// their dimensions differ
+func cosine(a, b models.Vector) (similarity float64, ok bool) {
+	if len(a) != len(b) {
+		return 0, false
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, false
+	}
+	return dot / math.Sqrt(normA*normB), true
+}
+
+// Skills is an in-memory SkillRepository
+type Skills struct {
+	mu     sync.Mutex
+	skills map[string]models.Skill
+}
+
+// NewSkills returns an empty skill table
+func NewSkills() *Skills {
+	return &Skills{skills: make(map[string]models.Skill)}
+}
+
+// UpsertSkills creates the skills that do not exist yet. Existing skills keep their category
+// unless they have none.
+func (r *Skills) UpsertSkills(ctx context.Context, skills []models.Skill) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, skill := range skills {
+		existing, ok := r.skills[skill.Name]
+		if ok && existing.Category != "" {
+			continue
+		}
+		if ok {
+			existing.Category = skill.Category
+			skill = existing
+		}
+		r.skills[skill.Name] = skill
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Package repotest provides in-memory repositories and a shared profile fixture, so that
+// services and handlers can be exercised without Postgres.
+package repotest
+
+import (
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// Resume is a generated resume citing the records of User
+const Resume = `# Jane Doe
+
+jane@example.com | +49 30 1234567 | Berlin
+
+## Experience
+
+### Senior Backend Engineer, Acme Corp
+
+- Led the migration of the billing platform to Kubernetes [src: WE-12]
+- Split the billing platform into Go microservices [src: WE-12]
+
+## Education
+
+### MSc in Computer Science, TU Berlin
+
+- Thesis on distributed consensus [src: ED-3]
+`
+
+// JobDescription is the job description Resume was generated for
+const JobDescription = "We are looking for a backend engineer with Go and Kubernetes experience to build microservices."
+
+// User returns the profile Resume is based on, with one work experience (WE-12) and one
+// education (ED-3)
+func User() *models.User {
+	graduated := time.Date(2016, time.September, 1, 0, 0, 0, 0, time.UTC)
+	return &models.User{
+		ID:       1,
+		Email:    "jane@example.com",
+		FullName: "Jane Doe",
+		Phone:    "+49 30 1234567",
+		Location: "Berlin",
+		Title:    "Backend Engineer",
+		Summary:  "Backend engineer with eight years of Go experience.",
+		WorkExperience: []models.WorkExperience{{
+			ID:          12,
+			UserID:      1,
+			Company:     "Acme Corp",
+			Title:       "Senior Backend Engineer",
+			Location:    "Berlin",
+			StartDate:   time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC),
+			IsCurrent:   true,
+			Description: "Led the migration of the billing platform to Kubernetes and split it into Go microservices.",
+		}},
+		Education: []models.Education{{
+			ID:          3,
+			UserID:      1,
+			School:      "TU Berlin",
+			Degree:      "MSc",
+			Field:       "Computer Science",
+			Location:    "Berlin",
+			StartDate:   time.Date(2014, time.October, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:     &graduated,
+			Description: "Thesis on distributed consensus.",
+		}},
+	}
+}
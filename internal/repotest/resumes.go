@@ -0,0 +1,160 @@
+package repotest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repository"
+)
+
+// Resumes is an in-memory ResumeRepository. Resumes of users that do not exist cannot be
+// saved when the repository was given its users.
+type Resumes struct {
+	users *Users
+
+	mu      sync.Mutex
+	resumes map[uint]*models.Resume
+	nextID  uint
+	err     error
+}
+
+// NewResumes returns an empty repository checking the owners of resumes against users,
+// which may be nil
+func NewResumes(users *Users) *Resumes {
+	return &Resumes{users: users, resumes: make(map[uint]*models.Resume)}
+}
+
+// Fail makes the following calls return err, or succeed again when err is nil
+func (r *Resumes) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// All returns the stored resumes in the order they were created
+func (r *Resumes) All() []models.Resume {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	resumes := make([]models.Resume, 0, len(r.resumes))
+	for _, resume := range r.resumes {
+		resumes = append(resumes, *resume)
+	}
+	sort.Slice(resumes, func(i, j int) bool { return resumes[i].ID < resumes[j].ID })
+	return resumes
+}
+
+// CreateResume stores a new resume version
+func (r *Resumes) CreateResume(ctx context.Context, resume *models.Resume) error {
+	if r.users != nil {
+		if _, err := r.users.GetUserByID(ctx, resume.UserID); err != nil {
+			return repository.ErrResumeUserNotFound
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.nextID++
+	resume.ID = r.nextID
+	resume.CreatedAt = time.Now()
+	resume.UpdatedAt = resume.CreatedAt
+	if resume.Format == "" {
+		resume.Format = models.ResumeFormatMarkdown
+	}
+	if resume.IsDefault {
+		r.clearDefault(resume.UserID)
+	}
+	stored := *resume
+	r.resumes[resume.ID] = &stored
+	return nil
+}
+
+// GetResumesByUserID returns the resumes of a user, newest first
+func (r *Resumes) GetResumesByUserID(ctx context.Context, userID uint) ([]models.Resume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	resumes := []models.Resume{}
+	for _, resume := range r.resumes {
+		if resume.UserID == userID {
+			resumes = append(resumes, *resume)
+		}
+	}
+	sort.Slice(resumes, func(i, j int) bool { return resumes[i].ID > resumes[j].ID })
+	return resumes, nil
+}
+
+// GetResumeByID returns a resume of a user
+func (r *Resumes) GetResumeByID(ctx context.Context, userID, resumeID uint) (*models.Resume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	resume, ok := r.resumes[resumeID]
+	if !ok || resume.UserID != userID {
+		return nil, repository.ErrResumeNotFound
+	}
+	found := *resume
+	return &found, nil
+}
+
+// UpdateResume replaces a resume of a user, keeping its skills unless resume.Skills is set
+func (r *Resumes) UpdateResume(ctx context.Context, resume *models.Resume) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	stored, ok := r.resumes[resume.ID]
+	if !ok || stored.UserID != resume.UserID {
+		return repository.ErrResumeNotFound
+	}
+	resume.UpdatedAt = time.Now()
+	if resume.Format == "" {
+		resume.Format = models.ResumeFormatMarkdown
+	}
+	if resume.IsDefault {
+		r.clearDefault(resume.UserID)
+	}
+	updated := *resume
+	updated.CreatedAt = stored.CreatedAt
+	updated.Findings = stored.Findings
+	updated.Provenance = stored.Provenance
+	if resume.Skills == nil {
+		updated.Skills = stored.Skills
+	}
+	r.resumes[resume.ID] = &updated
+	return nil
+}
+
+// DeleteResume removes a resume of a user
+func (r *Resumes) DeleteResume(ctx context.Context, userID, resumeID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	resume, ok := r.resumes[resumeID]
+	if !ok || resume.UserID != userID {
+		return repository.ErrResumeNotFound
+	}
+	delete(r.resumes, resumeID)
+	return nil
+}
+
+// clearDefault unsets the default flag on the resumes of a user
+func (r *Resumes) clearDefault(userID uint) {
+	for _, resume := range r.resumes {
+		if resume.UserID == userID {
+			resume.IsDefault = false
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Users is an in-memory UserRepository. Users that do not exist are reported with
+// gorm.ErrRecordNotFound, as by the real repository.
+type Users struct {
+	mu     sync.Mutex
+	users  map[uint]*models.User
+	nextID uint
+}
+
+// NewUsers returns a repository holding users
+func NewUsers(users ...*models.User) *Users {
+	r := &Users{users: make(map[uint]*models.User)}
+	for _, user := range users {
+		r.users[user.ID] = user
+		r.nextID = max(r.nextID, user.ID)
+	}
+	return r
+}
+
+// CreateUser stores a new user
+func (r *Users) CreateUser(ctx context.Context, user *models.User) error {
+	return r.CreateUserTx(ctx, nil, user)
+}
+
+// CreateUserTx stores a new user; the transaction is ignored
+func (r *Users) CreateUserTx(ctx context.Context, tx interfaces.Tx, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	user.ID = r.nextID
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	stored := *user
+	stored.WorkExperience = nil
+	stored.Education = nil
+	r.users[user.ID] = &stored
+	return nil
+}
+
+// CreateWorkExperiencesTx adds work experience to the users it belongs to
+func (r *Users) CreateWorkExperiencesTx(ctx context.Context, tx interfaces.Tx, experiences []models.WorkExperience) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, exp := range experiences {
+		user, ok := r.users[exp.UserID]
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+		user.WorkExperience = append(user.WorkExperience, exp)
+	}
+	return nil
+}
+
+// CreateEducationsTx adds education to the users it belongs to
+func (r *Users) CreateEducationsTx(ctx context.Context, tx interfaces.Tx, educations []models.Education) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, edu := range educations {
+		user, ok := r.users[edu.UserID]
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+		user.Education = append(user.Education, edu)
+	}
+	return nil
+}
+
+// GetUserByID returns a user without their work experience and education
+func (r *Users) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	user, err := r.GetUserWithDetails(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.WorkExperience = nil
+	user.Education = nil
+	return user, nil
+}
+
+// GetUserByEmail returns a user without their work experience and education
+func (r *Users) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			found := *user
+			found.WorkExperience = nil
+			found.Education = nil
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetUserWithDetails returns a copy of a user with their work experience and education
+func (r *Users) GetUserWithDetails(ctx context.Context, id uint) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *user
+	found.WorkExperience = append([]models.WorkExperience(nil), user.WorkExperience...)
+	found.Education = append([]models.Education(nil), user.Education...)
+	return &found, nil
+}
+
+// UpdateUser replaces a user
+func (r *Users) UpdateUser(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.ID]; !ok {
+		return errors.New("user not found")
+	}
+	user.UpdatedAt = time.Now()
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // SetupRouter configures all the routes for our application
-func SetupRouter(userHandler *handlers.UserHandler, resumeHandler *handlers.ResumeHandler) *gin.Engine {
+func SetupRouter(userHandler *handlers.UserHandler, resumeHandler *handlers.ResumeHandler, jobHandler *handlers.JobHandler, modelHandler *handlers.ModelHandler, refinementHandler *handlers.RefinementHandler, keywordHandler *handlers.KeywordHandler) *gin.Engine {
 	router := gin.Default()
 
 	// Middleware
@@ -24,11 +24,56 @@ func SetupRouter(userHandler *handlers.UserHandler, resumeHandler *handlers.Resu
 			users.GET("/:id", userHandler.GetUser)
 			users.GET("/email/:email", userHandler.GetUserByEmail)
 			users.PUT("/:id", userHandler.UpdateUser)
+
+			// Saved resume versions
+			users.GET("/:id/resumes", resumeHandler.ListResumes)
+			users.POST("/:id/resumes", resumeHandler.CreateResume)
+			users.POST("/:id/resumes/similar", resumeHandler.SimilarResumes)
+			users.GET("/:id/resumes/:resumeId", resumeHandler.GetResume)
+			users.PUT("/:id/resumes/:resumeId", resumeHandler.UpdateResume)
+			users.DELETE("/:id/resumes/:resumeId", resumeHandler.DeleteResume)
+
+			// Refinement sessions
+			users.GET("/:id/resumes/:resumeId/sessions", refinementHandler.ListSessions)
+			users.POST("/:id/resumes/:resumeId/sessions", refinementHandler.CreateSession)
+			users.GET("/:id/sessions/:sessionId", refinementHandler.GetSession)
+			users.DELETE("/:id/sessions/:sessionId", refinementHandler.DeleteSession)
+			users.POST("/:id/sessions/:sessionId/turns", refinementHandler.Refine)
+			users.POST("/:id/sessions/:sessionId/rollback", refinementHandler.Rollback)
 		}
 
 		// Resume generation route
 		v1.POST("/generate", resumeHandler.GenerateResume)
 
+		// Targeted edit route
+		v1.POST("/edit", resumeHandler.EditResume)
+
+		// Keyword routes
+		v1.POST("/keywords/extract", keywordHandler.ExtractKeywords)
+		v1.POST("/match", keywordHandler.Match)
+
+		// LLM model routes
+		v1.GET("/models", modelHandler.ListModels)
+
+		// Generation job routes
+		jobs := v1.Group("/jobs")
+		{
+			jobs.GET("/:id", jobHandler.GetJob)
+			jobs.GET("/:id/events", jobHandler.StreamJob)
+			jobs.DELETE("/:id", jobHandler.CancelJob)
+		}
+
+		// Keyword corpus administration routes
+		admin := v1.Group("/admin/keywords")
+		{
+			admin.GET("/stats", keywordHandler.GetCorpusStats)
+			admin.POST("/documents", keywordHandler.IngestDocument)
+			admin.POST("/recompute", keywordHandler.RecomputeStatistics)
+		}
+
+		// PDF rendering route
+		v1.POST("/pdf", resumeHandler.DownloadPDF)
+
 		// Onboarding route
 		v1.POST("/onboarding", userHandler.HandleOnboarding)
 	}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrFragmentNotFound is returned when an edit selector matches nothing in the resume
+	ErrFragmentNotFound = errors.New("selector matches no fragment of the resume")
+	// ErrInvalidSelector is returned when an edit selector names neither a section nor a bullet
+	ErrInvalidSelector = errors.New("selector needs a section, bullet or match")
+	// ErrEmptyEdit is returned when the model returns no content for the fragment, which
+	// would otherwise delete it
+	ErrEmptyEdit = errors.New("model returned no content")
+)
+
+// EditSelector picks the fragment of a markdown resume to rewrite. Section alone selects the
+// body of a section; Bullet or Match narrow it to a single bullet point with its continuation lines.
+type EditSelector struct {
+	Section string `json:"section,omitempty"` // Heading text, matched case-insensitively; the whole resume when empty
+	Bullet  int    `json:"bullet,omitempty"`  // 1-based index of a bullet point within the section
+	Match   string `json:"match,omitempty"`   // Text contained in the bullet point, matched case-insensitively
+}
+
+// EditParams holds the inputs of a targeted edit
+type EditParams struct {
+	UserID      uint
+	Content     string // Current resume markdown
+	Selector    EditSelector
+	Instruction string
+	Options     *GenerationOptions
+}
+
+// ResumePatch is a replacement of a line range of the submitted resume. Clients apply it by
+// replacing lines StartLine to EndLine of the content whose hash is BaseHash with Replacement.
+type ResumePatch struct {
+	BaseHash    string `json:"baseHash"`  // SHA-256 of the submitted content
+	StartLine   int    `json:"startLine"` // First replaced line, 1-based
+	EndLine     int    `json:"endLine"`   // Last replaced line, inclusive
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	Diff        string `json:"diff"` // Unified diff of the change
+}
+
+// EditResume has the LLM rewrite only the selected fragment of a resume and returns the change
+// as a patch together with the patched content
+func (s *ResumeService) EditResume(ctx context.Context, params EditParams) (*ResumePatch, string, error) {
+	if s.llm == nil {
+		return nil, "", fmt.Errorf("LLM service is not available")
+	}
+
+	lines := strings.Split(params.Content, "\n")
+	start, end, err := selectFragment(lines, params.Selector)
+	if err != nil {
+		return nil, "", err
+	}
+	original := lines[start:end]
+
+	req := GenerationRequest{
+		Model:   s.GenerationModel(params.Options),
+		System:  editSystemPrompt,
+		Prompt:  buildEditPrompt(params.Content, strings.Join(original, "\n"), params.Instruction),
+		Options: params.Options,
+		UserID:  params.UserID,
+	}
+	output, err := s.llm.GenerateContent(ctx, req)
+	if errors.Is(err, ErrLLMQueueFull) {
+		return nil, "", err
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to edit resume: %v", err)
+	}
+
+	replacement, err := cleanFragment(output, original)
+	if err != nil {
+		return nil, "", err
+	}
+	patched := append(append(append([]string{}, lines[:start]...), replacement...), lines[end:]...)
+
+	sum := sha256.Sum256([]byte(params.Content))
+	patch := &ResumePatch{
+		BaseHash:    hex.EncodeToString(sum[:]),
+		StartLine:   start + 1,
+		EndLine:     end,
+		Original:    strings.Join(original, "\n"),
+		Replacement: strings.Join(replacement, "\n"),
+		Diff:        unifiedDiff(original, replacement, start+1),
+	}
+	return patch, strings.Join(patched, "\n"), nil
+}
+
+// editSystemPrompt holds the instructions for targeted edits, sent as the system message
+const editSystemPrompt = `You are a professional resume writer making a targeted edit to a resume in markdown format.
+
+Rewrite ONLY the fragment you are given according to the instruction. Respond with the rewritten fragment in markdown and nothing else: no explanations, no code fences and no other parts of the resume. Keep the markdown structure of the fragment, e.g. a bullet point stays a bullet point. Do not make up or add any information that is not in the resume or in the instruction.`
+
+// buildEditPrompt creates the user message of a targeted edit
+func buildEditPrompt(content, fragment, instruction string) string {
+	return fmt.Sprintf(
+		`Full resume, for context only:
+%s
+
+Fragment to rewrite:
+%s
+
+Instruction:
+%s`,
+		content, fragment, strings.TrimSpace(instruction),
+	)
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletPattern  = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+`)
+	fencePattern   = regexp.MustCompile("(?s)^```[a-zA-Z]*\n(.*?)\n?```$")
+)
+
+// selectFragment returns the line range [start, end) selected in a markdown document
+func selectFragment(lines []string, sel EditSelector) (int, int, error) {
+	if sel.Section == "" && sel.Bullet == 0 && sel.Match == "" {
+		return 0, 0, ErrInvalidSelector
+	}
+
+	start, end := 0, len(lines)
+	if sel.Section != "" {
+		var ok bool
+		start, end, ok = findSection(lines, sel.Section)
+		if !ok {
+			return 0, 0, ErrFragmentNotFound
+		}
+	}
+
+	if sel.Bullet == 0 && sel.Match == "" {
+		start, end = trimBlankLines(lines, start, end)
+		if start == end {
+			return 0, 0, ErrFragmentNotFound
+		}
+		return start, end, nil
+	}
+
+	// Collect the bullet points of the range with their continuation lines
+	match := strings.ToLower(strings.TrimSpace(sel.Match))
+	bullet := 0
+	for i := start; i < end; i++ {
+		m := bulletPattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		indent := len(m[1])
+		j := i + 1
+		for j < end && isContinuation(lines[j], indent) {
+			j++
+		}
+		bullet++
+
+		text := strings.ToLower(strings.Join(lines[i:j], " "))
+		if (sel.Bullet == 0 || sel.Bullet == bullet) && (match == "" || strings.Contains(text, match)) {
+			return i, j, nil
+		}
+		i = j - 1
+	}
+	return 0, 0, ErrFragmentNotFound
+}
+
+// findSection returns the body of the first section whose heading contains name: the lines
+// after the heading up to the next heading of the same or a higher level
+func findSection(lines []string, name string) (int, int, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, line := range lines {
+		m := headingPattern.FindStringSubmatch(line)
+		if m == nil || !strings.Contains(strings.ToLower(strings.Trim(m[2], "*_ ")), name) {
+			continue
+		}
+		level := len(m[1])
+		end := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if h := headingPattern.FindStringSubmatch(lines[j]); h != nil && len(h[1]) <= level {
+				end = j
+				break
+			}
+		}
+		return i + 1, end, true
+	}
+	return 0, 0, false
+}
+
+// isContinuation reports whether a line continues a bullet point indented by indent.
+// Wrapped text and nested bullet points are indented further than their bullet point.
+func isContinuation(line string, indent int) bool {
+	if strings.TrimSpace(line) == "" || headingPattern.MatchString(line) {
+		return false
+	}
+	return len(line)-len(strings.TrimLeft(line, " \t")) > indent
+}
+
+// trimBlankLines narrows [start, end) to exclude leading and trailing blank lines
+func trimBlankLines(lines []string, start, end int) (int, int) {
+	for start < end && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	for end > start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return start, end
+}
+
+// cleanFragment strips reasoning blocks and code fences from the model output and keeps
+// the bullet marker and indentation of a single bullet point that the model dropped. It
+// returns ErrEmptyEdit when nothing is left.
+func cleanFragment(output string, original []string) ([]string, error) {
+	output = strings.TrimSpace(thinkBlockPattern.ReplaceAllString(output, ""))
+	if m := fencePattern.FindStringSubmatch(output); m != nil {
+		output = strings.TrimSpace(m[1])
+	}
+	if output == "" {
+		return nil, ErrEmptyEdit
+	}
+	lines := strings.Split(output, "\n")
+
+	if m := bulletPattern.FindStringSubmatch(original[0]); m != nil && len(lines) > 0 {
+		if !bulletPattern.MatchString(lines[0]) {
+			lines[0] = m[0] + strings.TrimSpace(lines[0])
+		} else if !strings.HasPrefix(lines[0], m[1]) {
+			lines[0] = m[1] + strings.TrimLeft(lines[0], " \t")
+		}
+	}
+	return lines, nil
+}
+
+// unifiedDiff renders the change of a line range as a unified diff hunk starting at line start
+func unifiedDiff(original, replacement []string, start int) string {
+	// Longest common subsequence of the lines, so that unchanged lines show as context
+	n, m := len(original), len(replacement)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if original[i] == replacement[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "@@ -%d,%d +%d,%d @@\n", start, n, start, m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && original[i] == replacement[j]:
+			diff.WriteString(" " + original[i] + "\n")
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			diff.WriteString("-" + original[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+" + replacement[j] + "\n")
+			j++
+		}
+	}
+	return diff.String()
+}
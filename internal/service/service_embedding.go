@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// Embedder turns texts into vectors whose cosine similarity reflects how similar the texts are
+type Embedder interface {
+	// Embed returns the vector of a text
+	Embed(ctx context.Context, text string) (models.Vector, error)
+	// Dimensions returns the length of the vectors
+	Dimensions() int
+	// Name identifies the embedder; vectors of different embedders are not comparable
+	Name() string
+}
+
+// NewEmbedder creates the embedder selected by the configuration
+func NewEmbedder(cfg *config.EmbeddingConfig) (Embedder, error) {
+	if cfg.Dimensions <= 0 {
+		return nil, fmt.Errorf("invalid embedding dimensions %d", cfg.Dimensions)
+	}
+
+	switch cfg.Provider {
+	case config.EmbeddingProviderHashing:
+		return NewHashingEmbedder(cfg.Dimensions), nil
+	case config.EmbeddingProviderOllama:
+		client := &http.Client{Timeout: cfg.Timeout}
+		return NewOllamaEmbedder(client, cfg.BaseURL, cfg.Model, cfg.Dimensions), nil
+	}
+	return nil, fmt.Errorf("unsupported embedding provider %q", cfg.Provider)
+}
+
+// HashingEmbedder embeds the weighted keywords of a text by feature hashing. It needs no
+// model and is deterministic, so it works offline; similarity is lexical, not semantic.
+type HashingEmbedder struct {
+	dimensions int
+}
+
+// NewHashingEmbedder creates a hashing embedder producing vectors of the given length
+func NewHashingEmbedder(dimensions int) *HashingEmbedder {
+	return &HashingEmbedder{dimensions: dimensions}
+}
+
+// Embed hashes each keyword into a dimension of the vector, weighted by its score. A second
+// hash picks the sign so that collisions tend to cancel out.
+func (e *HashingEmbedder) Embed(ctx context.Context, text string) (models.Vector, error) {
+	opts := DefaultKeywordOptions()
+	opts.Limit = 0
+
+	vector := make(models.Vector, e.dimensions)
+	for _, k := range extractKeywords(text, opts) {
+		h := fnv.New64a()
+		h.Write([]byte(k.Term))
+		sum := h.Sum64()
+
+		weight := float32(k.Score)
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vector[sum%uint64(e.dimensions)] += weight
+	}
+	return normalizeVector(vector), nil
+}
+
+// Dimensions returns the length of the vectors
+func (e *HashingEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+// Name identifies the embedder
+func (e *HashingEmbedder) Name() string {
+	return fmt.Sprintf("%s/%d", config.EmbeddingProviderHashing, e.dimensions)
+}
+
+// OllamaEmbedder embeds texts with an embedding model served by Ollama
+type OllamaEmbedder struct {
+	client     *http.Client
+	baseURL    string
+	model      string
+	dimensions int
+}
+
+// NewOllamaEmbedder creates an embedder for the Ollama API at baseURL, e.g. http://localhost:11434/api
+func NewOllamaEmbedder(client *http.Client, baseURL, model string, dimensions int) *OllamaEmbedder {
+	return &OllamaEmbedder{
+		client:     client,
+		baseURL:    baseURL,
+		model:      model,
+		dimensions: dimensions,
+	}
+}
+
+// ollamaEmbeddingRequest is the request of the /api/embeddings endpoint
+type ollamaEmbeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// ollamaEmbeddingResponse is the response of the /api/embeddings endpoint
+type ollamaEmbeddingResponse struct {
+	Embedding []float64 `json:"embedding"`
+}
+
+// Embed returns the embedding of a text. Vectors whose length differs from the configured
+// dimensions are rejected, since they cannot be stored or compared.
+func (e *OllamaEmbedder) Embed(ctx context.Context, text string) (models.Vector, error) {
+	resp, err := postJSON(ctx, e.client, e.baseURL+"/embeddings", "", ollamaEmbeddingRequest{Model: e.model, Prompt: text})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var embedding ollamaEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedding); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding response: %v", err)
+	}
+	if len(embedding.Embedding) != e.dimensions {
+		return nil, fmt.Errorf("model %s returned %d dimensions, EMBEDDING_DIMENSIONS is %d", e.model, len(embedding.Embedding), e.dimensions)
+	}
+
+	vector := make(models.Vector, len(embedding.Embedding))
+	for i, x := range embedding.Embedding {
+		vector[i] = float32(x)
+	}
+	return normalizeVector(vector), nil
+}
+
+// Dimensions returns the length of the vectors
+func (e *OllamaEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+// Name identifies the embedder
+func (e *OllamaEmbedder) Name() string {
+	return config.EmbeddingProviderOllama + "/" + e.model
+}
+
+// normalizeVector scales a vector to unit length
+func normalizeVector(vector models.Vector) models.Vector {
+	norm := 0.0
+	for _, x := range vector {
+		norm += float64(x) * float64(x)
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] = float32(float64(vector[i]) / norm)
+		}
+	}
+	return vector
+}
+
+// CheckEmbeddingDimensions returns an error when the embedder produces vectors of another
+// length than the vector_embedding column stores
+func (s *KeywordService) CheckEmbeddingDimensions(ctx context.Context) error {
+	columnDimensions, err := s.keywordRepo.GetEmbeddingDimensions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the dimensions of keyword_vectors.vector_embedding: %v", err)
+	}
+	if columnDimensions >= 0 && columnDimensions != s.embedder.Dimensions() {
+		return fmt.Errorf(
+			"embedder %s produces %d dimensions but keyword_vectors.vector_embedding is vector(%d); set EMBEDDING_DIMENSIONS or migrate the column, e.g. ALTER TABLE keyword_vectors ALTER COLUMN vector_embedding TYPE vector(%d) USING NULL",
+			s.embedder.Name(), s.embedder.Dimensions(), columnDimensions, s.embedder.Dimensions(),
+		)
+	}
+	return nil
+}
+
+// isZeroVector reports whether all components of a vector are zero
+func isZeroVector(vector models.Vector) bool {
+	for _, x := range vector {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,670 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repository"
+)
+
+var (
+	// ErrJobQueueFull is returned when no more jobs can be queued
+	ErrJobQueueFull = errors.New("generation queue is full")
+	// ErrJobUserQueueFull is returned when a user already has too many queued jobs
+	ErrJobUserQueueFull = errors.New("too many of your generations are queued, try again later")
+	// ErrJobRunningElsewhere is returned when canceling a job that runs in another server process
+	ErrJobRunningElsewhere = errors.New("job is running on another server")
+
+	// errJobShutdown interrupts running jobs when the server shuts down; they are requeued
+	errJobShutdown = errors.New("server shutting down")
+	// errJobCanceled interrupts running jobs canceled by the client
+	errJobCanceled = errors.New("job canceled")
+	// errJobLockLost interrupts running jobs whose lock was taken over, e.g. after they were
+	// requeued as stale; their outcome is no longer recorded by this worker
+	errJobLockLost = errors.New("job lock lost")
+)
+
+// Types of job events, matching the event types of generation streams
+const (
+	JobEventChunk = "chunk"
+	JobEventDone  = "done"
+	JobEventError = "error"
+	JobEventMeta  = "meta"
+)
+
+// JobEvent is a buffered stream event of a job
+type JobEvent struct {
+	ID   string // "<epoch>-<sequence>", see JobStream
+	Type string
+	Data map[string]interface{}
+}
+
+// JobStream buffers the events of a job in memory so that clients can reconnect and resume.
+// Each stream has an epoch; event IDs of another epoch (e.g. from before a restart) cannot
+// be resumed, so those clients receive a reset event followed by the whole stream.
+type JobStream struct {
+	epoch string
+
+	mu        sync.Mutex
+	events    []JobEvent
+	changed   chan struct{} // Closed and replaced whenever events are added
+	ended     bool          // No more events will be added by this process
+	updatedAt time.Time
+
+	queued        bool // The job waits for a worker
+	queuePosition int  // Last reported position in the job queue
+	followers     int  // Clients following the stream, see JobService.Stream
+}
+
+func newJobStream() *JobStream {
+	return &JobStream{
+		epoch:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		changed:   make(chan struct{}),
+		updatedAt: time.Now(),
+	}
+}
+
+// EventsSince returns the events after lastEventID, a channel closed on the next change,
+// and whether the stream ended, in which case the returned events are the last ones
+func (s *JobStream) EventsSince(lastEventID string) ([]JobEvent, <-chan struct{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []JobEvent
+	next := 0
+	if epoch, seq, ok := strings.Cut(lastEventID, "-"); ok && epoch == s.epoch {
+		next, _ = strconv.Atoi(seq)
+	} else if lastEventID != "" {
+		// The client saw the events of an earlier stream; tell it to discard them
+		events = append(events, JobEvent{ID: s.epoch + "-0", Type: JobEventMeta, Data: map[string]interface{}{"reset": true}})
+	}
+	if next < 0 {
+		next = 0
+	}
+	if next < len(s.events) {
+		events = append(events, s.events[next:]...)
+	}
+	return events, s.changed, s.ended
+}
+
+// emit appends an event and wakes up waiting clients
+func (s *JobStream) emit(eventType string, data map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.emitLocked(eventType, data)
+	}
+}
+
+// end appends a final event and marks the stream as ended
+func (s *JobStream) end(eventType string, data map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.emitLocked(eventType, data)
+		s.ended = true
+	}
+}
+
+func (s *JobStream) emitLocked(eventType string, data map[string]interface{}) {
+	id := s.epoch + "-" + strconv.Itoa(len(s.events)+1)
+	s.events = append(s.events, JobEvent{ID: id, Type: eventType, Data: data})
+	s.updatedAt = time.Now()
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// endFromJob ends the stream with the stored outcome of a finished job. The whole result is
+// sent as a single chunk when this process did not stream the output itself.
+func (s *JobStream) endFromJob(job *models.GenerationJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+
+	if job.Status != models.JobStatusSucceeded {
+		s.emitLocked(JobEventError, map[string]interface{}{"error": job.Error})
+		s.ended = true
+		return
+	}
+
+	hasOutput := false
+	for _, event := range s.events {
+		if event.Type == JobEventChunk {
+			hasOutput = true
+			break
+		}
+	}
+	if !hasOutput && job.Result != "" {
+		s.emitLocked(JobEventChunk, map[string]interface{}{"chunk": job.Result})
+	}
+	var resumeID uint
+	if job.ResumeID != nil {
+		resumeID = *job.ResumeID
+	}
+	s.emitLocked(JobEventDone, map[string]interface{}{"resumeId": resumeID})
+	s.ended = true
+}
+
+// setQueued records whether the job waits for a worker, so that its queue position is reported
+func (s *JobStream) setQueued(queued bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued = queued
+	s.queuePosition = 0
+}
+
+// waiting reports whether the job waits for a worker and clients still follow the stream
+func (s *JobStream) waiting() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queued && !s.ended
+}
+
+// setQueuePosition emits the position of a queued job in the job queue when it changed
+func (s *JobStream) setQueuePosition(position int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended || !s.queued || position == s.queuePosition {
+		return
+	}
+	s.queuePosition = position
+	s.emitLocked(JobEventMeta, map[string]interface{}{"queuePosition": position})
+}
+
+// Unfollow is called by a client that stops following a stream returned by JobService.Stream
+func (s *JobStream) Unfollow() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.followers--
+	s.updatedAt = time.Now()
+}
+
+// expired reports whether a stream was idle since cutoff and is no longer needed: it ended, or
+// no client follows it, e.g. the stream of a job of another process whose clients left before
+// the job finished
+func (s *JobStream) expired(cutoff time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updatedAt.Before(cutoff) && (s.ended || s.followers == 0)
+}
+
+// JobService runs resume generations from a queue persisted in Postgres on a pool of workers,
+// decoupled from the HTTP requests that start them. Users are served round-robin and each
+// user can queue a limited number of jobs. Jobs interrupted by a shutdown are
+// requeued, and the jobs of crashed processes are requeued once their lock goes stale.
+type JobService struct {
+	jobRepo       interfaces.JobRepository
+	resumeService *ResumeService
+	cfg           *config.JobConfig
+	workerID      string
+
+	wake    chan struct{}
+	stop    context.CancelFunc
+	workers sync.WaitGroup
+
+	mu      sync.Mutex
+	streams map[string]*JobStream
+	running map[string]context.CancelCauseFunc
+}
+
+// NewJobService creates a new JobService; call Start to run its workers
+func NewJobService(jobRepo interfaces.JobRepository, resumeService *ResumeService, cfg *config.JobConfig) *JobService {
+	hostname, _ := os.Hostname()
+	return &JobService{
+		jobRepo:       jobRepo,
+		resumeService: resumeService,
+		cfg:           cfg,
+		workerID:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		wake:          make(chan struct{}, 1),
+		streams:       make(map[string]*JobStream),
+		running:       make(map[string]context.CancelCauseFunc),
+	}
+}
+
+// Start launches the workers and the maintenance loop
+func (s *JobService) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+
+	for i := 0; i < s.cfg.Workers; i++ {
+		s.workers.Add(1)
+		go s.worker(ctx)
+	}
+	go s.maintain(ctx)
+	go s.trackQueue(ctx)
+}
+
+// Shutdown stops claiming jobs, interrupts and requeues the running ones, and waits for the
+// workers to exit or ctx to expire
+func (s *JobService) Shutdown(ctx context.Context) error {
+	if s.stop != nil {
+		s.stop()
+	}
+
+	s.mu.Lock()
+	for _, cancel := range s.running {
+		cancel(errJobShutdown)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Submit queues a resume generation and returns its job
+func (s *JobService) Submit(ctx context.Context, params GenerateResumeParams) (*models.GenerationJob, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.GenerationJob{
+		ID:          id,
+		UserID:      params.UserID,
+		Params:      string(data),
+		MaxAttempts: s.cfg.MaxAttempts,
+	}
+	err = s.jobRepo.CreateJob(ctx, job, s.cfg.QueueSize, s.cfg.UserQueueSize)
+	if errors.Is(err, repository.ErrJobQueueFull) {
+		return nil, ErrJobQueueFull
+	}
+	if errors.Is(err, repository.ErrJobUserQueueFull) {
+		return nil, ErrJobUserQueueFull
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.stream(id).setQueued(true)
+	s.updateQueuePositions(ctx)
+
+	// Wake an idle worker instead of waiting for the next poll
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// Get returns a job by ID
+func (s *JobService) Get(ctx context.Context, id string) (*models.GenerationJob, error) {
+	return s.jobRepo.GetJob(ctx, id)
+}
+
+// Stream returns the event stream of a job; call Unfollow on it when done. The streams of jobs
+// that finished in another process, or before a restart, are rebuilt from the stored outcome.
+func (s *JobService) Stream(ctx context.Context, id string) (*JobStream, error) {
+	// Look the job up first so that unknown IDs do not leave streams behind
+	job, err := s.jobRepo.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Followed before the lock is released so that maintain does not drop the stream
+	s.mu.Lock()
+	stream := s.streamLocked(id)
+	stream.mu.Lock()
+	stream.followers++
+	stream.mu.Unlock()
+	s.mu.Unlock()
+	if job.Finished() {
+		stream.endFromJob(job)
+	} else if job.Status == models.JobStatusQueued {
+		stream.setQueued(true)
+	}
+	return stream, nil
+}
+
+// Refresh ends the stream of a job that finished in another process. Such streams receive
+// no events from this process, so clients call Refresh periodically while they are idle.
+func (s *JobService) Refresh(ctx context.Context, id string, stream *JobStream) error {
+	job, err := s.jobRepo.GetJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.Finished() {
+		stream.endFromJob(job)
+	}
+	return nil
+}
+
+// Cancel stops a queued or running job
+func (s *JobService) Cancel(ctx context.Context, id string) error {
+	job, err := s.jobRepo.GetJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.Finished() {
+		return nil
+	}
+
+	s.mu.Lock()
+	cancel, running := s.running[id]
+	s.mu.Unlock()
+	if running {
+		cancel(errJobCanceled)
+		return nil
+	}
+
+	canceled, err := s.jobRepo.CancelQueuedJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !canceled {
+		// Claimed by a worker of another process in the meantime
+		return ErrJobRunningElsewhere
+	}
+	s.stream(id).end(JobEventError, map[string]interface{}{"error": errJobCanceled.Error()})
+	return nil
+}
+
+// stream returns the in-memory stream of a job, creating it when missing
+func (s *JobService) stream(id string) *JobStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streamLocked(id)
+}
+
+// streamLocked is stream for callers holding s.mu
+func (s *JobService) streamLocked(id string) *JobStream {
+	stream, ok := s.streams[id]
+	if !ok {
+		stream = newJobStream()
+		stream.emit(JobEventMeta, map[string]interface{}{"source": "llm", "jobId": id})
+		s.streams[id] = stream
+	}
+	return stream
+}
+
+// worker claims and runs queued jobs until ctx is done
+func (s *JobService) worker(ctx context.Context) {
+	defer s.workers.Done()
+
+	for ctx.Err() == nil {
+		job, err := s.jobRepo.ClaimNextJob(ctx, s.workerID)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to claim generation job: %v", err)
+		}
+		if job != nil {
+			// The jobs behind the claimed one moved up
+			s.updateQueuePositions(ctx)
+			s.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-time.After(s.cfg.PollInterval):
+		}
+	}
+}
+
+// run executes a claimed job, buffering its output as stream events, and records the outcome.
+// The job is interrupted for a shutdown when workerCtx is done.
+func (s *JobService) run(workerCtx context.Context, job *models.GenerationJob) {
+	stream := s.stream(job.ID)
+	stream.setQueued(false)
+	if job.Attempts > 1 {
+		// A previous attempt may have streamed partial output
+		stream.emit(JobEventMeta, map[string]interface{}{"reset": true, "attempt": job.Attempts})
+	}
+
+	jobCtx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	s.mu.Lock()
+	s.running[job.ID] = cancel
+	s.mu.Unlock()
+	if workerCtx.Err() != nil {
+		// Shutdown started after the job was claimed but before it was registered
+		cancel(errJobShutdown)
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, job.ID)
+		s.mu.Unlock()
+	}()
+
+	stopHeartbeat := s.heartbeat(job.ID, cancel)
+	resume, err := s.generate(jobCtx, job, stream)
+	stopHeartbeat()
+
+	// Record the outcome even though the job context may be canceled
+	ctx, cancelUpdate := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelUpdate()
+
+	switch {
+	case err == nil:
+		if err := s.jobRepo.CompleteJob(ctx, job.ID, s.workerID, resume.ID, resume.Content); err != nil {
+			log.Printf("Failed to complete generation job %s: %v", job.ID, err)
+		}
+		stream.end(JobEventDone, map[string]interface{}{"resumeId": resume.ID, "findings": resume.Findings, "provenance": resume.Provenance})
+
+	case errors.Is(context.Cause(jobCtx), errJobShutdown):
+		if err := s.jobRepo.RequeueJob(ctx, job.ID, s.workerID); err != nil {
+			log.Printf("Failed to requeue generation job %s: %v", job.ID, err)
+		}
+		// Clients reconnect and follow the job once another process picks it up
+		stream.end(JobEventMeta, map[string]interface{}{"status": models.JobStatusQueued, "reason": errJobShutdown.Error()})
+
+	case errors.Is(context.Cause(jobCtx), errJobLockLost):
+		// The job was requeued, so the stream stays open: a worker of this process that claims
+		// the job again streams into it, and when a worker of another process finishes the job,
+		// clients following the stream get the stored outcome through Refresh
+		stream.emit(JobEventMeta, map[string]interface{}{"status": models.JobStatusQueued, "reason": errJobLockLost.Error()})
+		stream.setQueued(true)
+
+	case errors.Is(context.Cause(jobCtx), errJobCanceled):
+		if err := s.jobRepo.CancelRunningJob(ctx, job.ID, s.workerID); err != nil {
+			log.Printf("Failed to cancel generation job %s: %v", job.ID, err)
+		}
+		stream.end(JobEventError, map[string]interface{}{"error": errJobCanceled.Error()})
+
+	case job.Attempts < job.MaxAttempts:
+		retryAt := time.Now().Add(time.Duration(job.Attempts) * s.cfg.RetryBackoff)
+		if err := s.jobRepo.FailJob(ctx, job.ID, s.workerID, err.Error(), &retryAt); err != nil {
+			log.Printf("Failed to reschedule generation job %s: %v", job.ID, err)
+		}
+		stream.emit(JobEventMeta, map[string]interface{}{"status": models.JobStatusQueued, "error": err.Error(), "retryAt": retryAt})
+		stream.setQueued(true)
+
+	default:
+		if err := s.jobRepo.FailJob(ctx, job.ID, s.workerID, err.Error(), nil); err != nil {
+			log.Printf("Failed to fail generation job %s: %v", job.ID, err)
+		}
+		stream.end(JobEventError, map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// generate runs the resume generation of a job
+func (s *JobService) generate(ctx context.Context, job *models.GenerationJob, stream *JobStream) (*models.Resume, error) {
+	var params GenerateResumeParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		return nil, fmt.Errorf("invalid job parameters: %v", err)
+	}
+	// The job queue bounds and orders jobs already, so the call waits for the model instead of
+	// failing with ErrLLMQueueFull; queue positions are reported from the job queue
+	params.Background = true
+
+	return s.resumeService.GenerateResume(ctx, params, func(chunk string, done bool) error {
+		if chunk != "" {
+			stream.emit(JobEventChunk, map[string]interface{}{"chunk": chunk})
+		}
+		return nil
+	})
+}
+
+// heartbeat refreshes the lock of a running job until the returned function is called. The
+// job is interrupted through cancelJob when its lock was lost.
+func (s *JobService) heartbeat(id string, cancelJob context.CancelCauseFunc) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(s.cfg.StaleAfter / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := s.jobRepo.TouchJob(ctx, id, s.workerID)
+				if errors.Is(err, repository.ErrJobLockLost) {
+					log.Printf("Lost the lock of generation job %s, stopping it", id)
+					cancelJob(errJobLockLost)
+					return
+				}
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Failed to refresh generation job %s: %v", id, err)
+				}
+			}
+		}
+	}()
+	return cancel
+}
+
+// trackQueue reports the queue positions of waiting jobs until ctx is done, including moves
+// caused by other processes
+func (s *JobService) trackQueue(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.updateQueuePositions(ctx)
+		}
+	}
+}
+
+// updateQueuePositions emits the positions of the queued jobs followed by streams of this process
+func (s *JobService) updateQueuePositions(ctx context.Context) {
+	waiting := make(map[string]*JobStream)
+	s.mu.Lock()
+	for id, stream := range s.streams {
+		if stream.waiting() {
+			waiting[id] = stream
+		}
+	}
+	s.mu.Unlock()
+	if len(waiting) == 0 {
+		return
+	}
+
+	jobs, err := s.jobRepo.ListQueuedJobs(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to list queued generation jobs: %v", err)
+		}
+		return
+	}
+	for id, position := range queuePositions(jobs) {
+		if stream, ok := waiting[id]; ok {
+			stream.setQueuePosition(position)
+		}
+	}
+}
+
+// queuePositions estimates the 1-based positions of queued jobs, given oldest first. Workers
+// serve users round-robin, so the n-th job of each user is claimed in the n-th round, the
+// users taking turns in the order of their oldest job.
+func queuePositions(jobs []models.GenerationJob) map[string]int {
+	type queuedJob struct {
+		id    string
+		round int
+		user  int
+	}
+
+	users := make(map[uint]int)
+	rounds := make(map[uint]int)
+	order := make([]queuedJob, 0, len(jobs))
+	for _, job := range jobs {
+		user, ok := users[job.UserID]
+		if !ok {
+			user = len(users)
+			users[job.UserID] = user
+		}
+		order = append(order, queuedJob{id: job.ID, round: rounds[job.UserID], user: user})
+		rounds[job.UserID]++
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].round != order[j].round {
+			return order[i].round < order[j].round
+		}
+		return order[i].user < order[j].user
+	})
+
+	positions := make(map[string]int, len(order))
+	for i, job := range order {
+		positions[job.id] = i + 1
+	}
+	return positions
+}
+
+// maintain requeues stale jobs and drops expired streams until ctx is done
+func (s *JobService) maintain(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		// Runs right away so that the jobs of a crashed process are picked up on startup
+		requeued, failed, err := s.jobRepo.RequeueStaleJobs(ctx, time.Now().Add(-s.cfg.StaleAfter))
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to requeue stale generation jobs: %v", err)
+		} else if requeued > 0 || failed > 0 {
+			log.Printf("Requeued %d stale generation jobs, failed %d out of attempts", requeued, failed)
+		}
+
+		cutoff := time.Now().Add(-s.cfg.Retention)
+		s.mu.Lock()
+		for id, stream := range s.streams {
+			// A worker of this process still streams into the streams of running jobs
+			if _, running := s.running[id]; !running && stream.expired(cutoff) {
+				delete(s.streams, id)
+			}
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newJobID returns a random 32 character hex ID
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
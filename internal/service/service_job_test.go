@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repotest"
+)
+
+func TestJobStreamExpiry(t *testing.T) {
+	stream := newJobStream()
+	stream.followers++
+	later := time.Now().Add(time.Hour)
+
+	if stream.expired(later) {
+		t.Error("a followed stream expired before its job finished")
+	}
+
+	stream.Unfollow()
+	if stream.expired(time.Now().Add(-time.Hour)) {
+		t.Error("a stream expired before it was idle for the retention")
+	}
+	if !stream.expired(later) {
+		t.Error("an idle stream nobody follows did not expire")
+	}
+
+	ended := newJobStream()
+	ended.followers++
+	ended.end(JobEventDone, map[string]interface{}{})
+	if !ended.expired(later) {
+		t.Error("an idle ended stream did not expire")
+	}
+}
+
+func TestSubmitQueueLimits(t *testing.T) {
+	cfg := &config.JobConfig{QueueSize: 3, UserQueueSize: 2, MaxAttempts: 1, Retention: time.Minute}
+	s := NewJobService(repotest.NewJobs(), nil, cfg)
+
+	submits := []struct {
+		userID uint
+		want   error
+	}{
+		{1, nil},
+		{1, nil},
+		{1, ErrJobUserQueueFull},
+		{2, nil},
+		{3, ErrJobQueueFull},
+	}
+	for i, submit := range submits {
+		_, err := s.Submit(context.Background(), GenerateResumeParams{UserID: submit.userID})
+		if !errors.Is(err, submit.want) {
+			t.Errorf("submit %d of user %d: got error %v, want %v", i+1, submit.userID, err, submit.want)
+		}
+	}
+}
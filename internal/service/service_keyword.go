@@ -1,169 +1,253 @@
 package service
 
 import (
+	"context"
 	"sort"
 	"strings"
 
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
 	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
 	"github.com/nikolai/ai-resume-builder/backend/internal/models"
 )
 
 type KeywordService struct {
-	db interfaces.DB
+	db          interfaces.DB
+	keywordRepo interfaces.KeywordRepository
+	skillRepo   interfaces.SkillRepository
+	cfg         *config.KeywordConfig
+	embedder    Embedder
 }
 
-func NewKeywordService(db interfaces.DB) *KeywordService {
+func NewKeywordService(db interfaces.DB, keywordRepo interfaces.KeywordRepository, skillRepo interfaces.SkillRepository, cfg *config.KeywordConfig, embedder Embedder) *KeywordService {
 	return &KeywordService{
-		db: db,
+		db:          db,
+		keywordRepo: keywordRepo,
+		skillRepo:   skillRepo,
+		cfg:         cfg,
+		embedder:    embedder,
 	}
 }
 
-// Common English stopwords
-var stopwords = map[string]bool{
-	"a": true, "about": true, "above": true, "after": true, "again": true, "against": true,
-	"all": true, "am": true, "an": true, "and": true, "any": true, "are": true, "as": true,
-	"at": true, "be": true, "because": true, "been": true, "before": true, "being": true,
-	"below": true, "between": true, "both": true, "but": true, "by": true, "could": true,
-	"did": true, "do": true, "does": true, "doing": true, "down": true, "during": true,
-	// ... other stopwords (keep the ones you had before)
-}
-
-// Extract and Rank Keywords from text
-func (s *KeywordService) ExtractAndRankKeywords(text string) []models.Keyword {
-	// Preprocessing: lowercase the text and replace punctuation with spaces
-	text = strings.ToLower(text)
-	text = cleanText(text)
+// Common multi-word phrases to look for besides the skills of the taxonomy. They take
+// precedence over skill aliases they contain, e.g. "scrum master" over "scrum".
+var commonPhrases = []string{
+	// Job titles
+	"software engineer", "backend engineer", "frontend engineer", "full stack engineer",
+	"software developer", "web developer", "devops engineer", "data engineer",
+	"system administrator", "database administrator", "cloud architect", "solutions architect",
+	"product manager", "scrum master", "technical lead", "engineering manager",
 
-	// Define common multi-word technical phrases to look for
-	commonPhrases := []string{
-		// Job titles
-		"software engineer", "backend engineer", "frontend engineer", "full stack engineer",
-		"software developer", "web developer", "devops engineer", "data engineer",
-		"system administrator", "database administrator", "cloud architect", "solutions architect",
-		"product manager", "scrum master", "technical lead", "engineering manager",
+	// Skills and concepts
+	"javascript framework", "database optimization", "predictive modeling",
+}
 
-		// Technologies and frameworks
-		"restful api", "restful apis", "microservices architecture", "service oriented architecture",
-		"continuous integration", "continuous deployment", "ci/cd pipeline", "git workflow",
-		"test driven development", "agile methodology", "scrum methodology", "kanban methodology",
+// commonPhraseSet holds the stems of the common phrases for lookup
+var commonPhraseSet = func() map[string]bool {
+	set := make(map[string]bool, len(commonPhrases))
+	for _, phrase := range commonPhrases {
+		set[stemPhrase(phrase)] = true
+	}
+	return set
+}()
 
-		// Skills and concepts
-		"cloud infrastructure", "distributed systems", "system design", "database design",
-		"api design", "object oriented programming", "functional programming", "version control",
-		"data structures", "design patterns", "unit testing", "integration testing",
+// KeywordOptions tunes keyword extraction
+type KeywordOptions struct {
+	Limit        int     // Maximum number of keywords returned; all when 0
+	PhraseWeight float64 // Score multiplier of multi-word terms
+	Bigrams      bool    // Whether adjacent word pairs are extracted as terms besides the known phrases
+	Language     string  // Language of the text, which selects the stopwords; detected when empty
+}
 
-		// Cloud platforms and tools
-		"aws cloud", "microsoft azure", "google cloud", "cloud computing",
-		"amazon web services", "aws lambda", "aws ec2", "aws s3",
-		"docker containers", "kubernetes orchestration", "terraform", "infrastructure as code",
+// DefaultKeywordOptions returns the options ExtractAndRankKeywords uses
+func DefaultKeywordOptions() KeywordOptions {
+	return KeywordOptions{
+		Limit:        50,
+		PhraseWeight: 1.5,
+		Bigrams:      true,
+	}
+}
 
-		// Programming languages with context
-		"golang development", "python programming", "javascript framework", "typescript development",
-		"java enterprise", "c++ programming", "react development", "node.js development",
+// Extract and Rank Keywords from text, weighted against the corpus of job descriptions
+func (s *KeywordService) ExtractAndRankKeywords(ctx context.Context, text string) []models.Keyword {
+	return s.RankKeywords(ctx, text, DefaultKeywordOptions())
+}
 
-		// Databases
-		"postgresql database", "mysql database", "mongodb database", "redis cache",
-		"elasticsearch", "sqlite database", "dynamodb", "database optimization",
+// ExtractKeywords extracts keywords from text and ranks them by weighted term frequency
+// within the text alone
+func (s *KeywordService) ExtractKeywords(text string, opts KeywordOptions) []models.Keyword {
+	return extractKeywords(text, opts)
+}
 
-		// Machine learning and data
-		"machine learning", "data analysis", "data visualization", "big data",
-		"natural language processing", "computer vision", "predictive modeling", "neural networks",
+func extractKeywords(text string, opts KeywordOptions) []models.Keyword {
+	// Preprocessing: lowercase the text, split it into words without punctuation and replace
+	// the aliases of known skills with their placeholder tokens
+	tokens := taxonomy.normalize(text, commonPhraseSet)
+	lang := opts.Language
+	if lang == "" {
+		lang = detectLanguage(tokens)
 	}
+	stop := stopwords[lang]
+	terms := termKeys(tokens, lang)
+	used := make([]bool, len(tokens))
+	counts := make(map[string]*termCount)
 
 	// Count phrases first
-	phraseCount := make(map[string]int)
 	for _, phrase := range commonPhrases {
-		paddedPhrase := " " + phrase + " "
-		paddedText := " " + text + " "
-		count := strings.Count(paddedText, paddedPhrase)
-		if count > 0 {
-			phraseCount[phrase] = count
-			text = strings.ReplaceAll(paddedText, paddedPhrase, " "+strings.Repeat("X", len(phrase))+" ")
-			text = strings.TrimSpace(text)
-		}
+		countPhrase(tokens, terms, used, strings.Fields(stemPhrase(phrase)), counts)
 	}
 
-	// Split text into tokens for further processing
-	text = cleanText(text)
-	tokens := strings.Fields(text)
-
 	// Extract potential n-gram phrases
-	if len(tokens) >= 2 {
-		for i := 0; i < len(tokens)-1; i++ {
-			word1 := tokens[i]
-			word2 := tokens[i+1]
-
-			if stopwords[word1] || stopwords[word2] ||
-				len(word1) <= 2 || len(word2) <= 2 ||
-				strings.Contains(word1, "X") || strings.Contains(word2, "X") {
+	if opts.Bigrams {
+		for i := 0; i+1 < len(tokens); i++ {
+			if used[i] || used[i+1] || !isBigramWord(tokens[i], stop) || !isBigramWord(tokens[i+1], stop) {
 				continue
 			}
-
-			bigram := word1 + " " + word2
-
-			if _, exists := phraseCount[bigram]; !exists {
-				paddedBigram := " " + bigram + " "
-				paddedText := " " + text + " "
-				count := strings.Count(paddedText, paddedBigram)
-				if count > 0 {
-					phraseCount[bigram] = count
-					text = strings.ReplaceAll(paddedText, paddedBigram, " "+strings.Repeat("X", len(bigram))+" ")
-					text = strings.TrimSpace(text)
-				}
-			}
+			countPhrase(tokens, terms, used, terms[i:i+2], counts)
 		}
 	}
 
-	// Process individual words
-	words := strings.Fields(text)
-	wordCount := make(map[string]int)
-	totalTerms := 0
-
-	// Add phrase counts
-	for phrase, count := range phraseCount {
-		wordCount[phrase] = count
-		totalTerms += count
-	}
-
-	// Add individual word counts
-	for _, word := range words {
-		if strings.Contains(word, "X") {
+	// Process individual words; skills count under their canonical term, other words under
+	// their stem in English and as they are in other languages
+	for i, word := range tokens {
+		if used[i] {
 			continue
 		}
-		if len(word) > 2 && !stopwords[word] {
-			wordCount[word]++
-			totalTerms++
+		if skill, ok := taxonomy.skill(word); ok {
+			addTerm(counts, terms[i], skill.Name, skill.Category)
+		} else if len(word) > 2 && !stop[word] {
+			addTerm(counts, terms[i], word, "")
 		}
 	}
 
+	totalTerms := 0
+	for _, c := range counts {
+		totalTerms += c.count
+	}
+
 	// Calculate term frequency and create keywords
-	var keywords []models.Keyword
-	for term, count := range wordCount {
-		tf := float64(count) / float64(totalTerms)
+	keywords := []models.Keyword{}
+	for term, c := range counts {
+		tf := float64(c.count) / float64(totalTerms)
 		wordWeight := 1.0
 		if strings.Contains(term, " ") {
-			wordWeight = 1.5
+			wordWeight = opts.PhraseWeight
 		}
 		score := tf * wordWeight
 
 		keywords = append(keywords, models.Keyword{
-			Word:  term,
-			Score: score,
-			Count: count,
+			Word:     c.display(),
+			Term:     term,
+			Score:    score,
+			Count:    c.count,
+			Category: c.category,
 		})
 	}
 
-	// Sort by score
+	return sortKeywords(keywords, opts.Limit)
+}
+
+// termCount holds the occurrences of a term and the spellings it occurred in
+type termCount struct {
+	count    int
+	forms    map[string]int
+	category string
+}
+
+// display returns the most frequent spelling of a term, the shortest one on ties, e.g.
+// "develop" for one each of "develop", "developing" and "developer"
+func (c *termCount) display() string {
+	best := ""
+	for form, n := range c.forms {
+		if best == "" || n > c.forms[best] ||
+			(n == c.forms[best] && (len(form) < len(best) || (len(form) == len(best) && form < best))) {
+			best = form
+		}
+	}
+	return best
+}
+
+// addTerm counts an occurrence of a term spelled as form
+func addTerm(counts map[string]*termCount, term, form, category string) {
+	c, ok := counts[term]
+	if !ok {
+		c = &termCount{forms: make(map[string]int), category: category}
+		counts[term] = c
+	}
+	c.count++
+	c.forms[form]++
+}
+
+// countPhrase counts the occurrences of a phrase, given by the terms of its words, that
+// overlap no term counted before, and marks their tokens as used
+func countPhrase(tokens, terms []string, used []bool, phrase []string, counts map[string]*termCount) {
+	n := len(phrase)
+	key := strings.Join(phrase, " ")
+	if _, ok := counts[key]; ok || n == 0 {
+		return
+	}
+	for i := 0; i+n <= len(tokens); i++ {
+		match := true
+		for j := 0; j < n && match; j++ {
+			match = !used[i+j] && terms[i+j] == phrase[j]
+		}
+		if !match {
+			continue
+		}
+		for j := i; j < i+n; j++ {
+			used[j] = true
+		}
+		addTerm(counts, key, displayPhrase(tokens[i:i+n]), "")
+		i += n - 1
+	}
+}
+
+// displayPhrase joins the tokens of a phrase for display, spelling skills by their canonical name
+func displayPhrase(tokens []string) string {
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token
+		if skill, ok := taxonomy.skill(token); ok {
+			words[i] = skill.Name
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// isBigramWord reports whether a token may be part of an extracted word pair
+func isBigramWord(token string, stop map[string]bool) bool {
+	return len(token) > 2 && !stop[token] && !isSkillToken(token)
+}
+
+// termKeys returns the term each token counts under: the canonical term of a skill, or the
+// stem of a word for English, whose rules the stemmer implements
+func termKeys(tokens []string, lang string) []string {
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		if skill, ok := taxonomy.skill(token); ok {
+			terms[i] = skill.Term()
+		} else if lang == LanguageEnglish {
+			terms[i] = stem(token)
+		} else {
+			terms[i] = token
+		}
+	}
+	return terms
+}
+
+// sortKeywords sorts keywords by score and returns up to limit of them, all when limit is 0.
+// Ties are ordered alphabetically so that the ranking is stable.
+func sortKeywords(keywords []models.Keyword, limit int) []models.Keyword {
 	sort.Slice(keywords, func(i, j int) bool {
-		return keywords[i].Score > keywords[j].Score
+		if keywords[i].Score != keywords[j].Score {
+			return keywords[i].Score > keywords[j].Score
+		}
+		return keywords[i].Word < keywords[j].Word
 	})
 
-	// Return top keywords
-	maxKeywords := 50
-	if len(keywords) < maxKeywords {
-		maxKeywords = len(keywords)
+	if limit > 0 && len(keywords) > limit {
+		keywords = keywords[:limit]
 	}
-	return keywords[:maxKeywords]
+	return keywords
 }
 
 // cleanText replaces punctuation with spaces and cleans up the text
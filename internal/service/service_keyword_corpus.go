@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// Okapi BM25 parameters: term frequency saturation and document length normalization
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// RankKeywords extracts the keywords of a text and scores them against the document
+// frequencies of the corpus of job descriptions, so that terms common to all postings rank
+// below the distinctive ones. Until the corpus has enough documents, or when the statistics
+// cannot be read, keywords are ranked by term frequency alone.
+func (s *KeywordService) RankKeywords(ctx context.Context, text string, opts KeywordOptions) []models.Keyword {
+	limit := opts.Limit
+	opts.Limit = 0
+	keywords := s.ExtractKeywords(text, opts)
+
+	if s.cfg.Scoring != config.KeywordScoringTF && s.keywordRepo != nil {
+		if err := s.weightByCorpus(ctx, keywords, opts.PhraseWeight); err != nil {
+			log.Printf("Failed to weight keywords by corpus statistics: %v", err)
+		}
+	}
+	return sortKeywords(keywords, limit)
+}
+
+// weightByCorpus replaces the term frequency scores of keywords with TF-IDF or BM25 weights
+func (s *KeywordService) weightByCorpus(ctx context.Context, keywords []models.Keyword, phraseWeight float64) error {
+	stats, err := s.keywordRepo.GetCorpusStats(ctx)
+	if err != nil {
+		return err
+	}
+	if stats.DocumentCount < s.cfg.MinCorpusDocuments {
+		return nil
+	}
+
+	terms := make([]string, 0, len(keywords))
+	length := 0
+	for _, k := range keywords {
+		terms = append(terms, k.Term)
+		length += k.Count
+	}
+	frequencies, err := s.keywordRepo.GetDocumentFrequencies(ctx, terms)
+	if err != nil {
+		return err
+	}
+
+	n := float64(stats.DocumentCount)
+	for i := range keywords {
+		k := &keywords[i]
+		df := float64(frequencies[k.Term])
+		count := float64(k.Count)
+
+		var score float64
+		if s.cfg.Scoring == config.KeywordScoringTFIDF {
+			score = count / float64(length) * (math.Log((n+1)/(df+1)) + 1)
+		} else {
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(length)/stats.AverageLength()
+			score = idf * count * (bm25K1 + 1) / (count + bm25K1*norm)
+		}
+		if strings.Contains(k.Term, " ") {
+			score *= phraseWeight
+		}
+		k.Score = score
+	}
+	return nil
+}
+
+// IngestJobDescription adds a job description to the corpus the keyword statistics are
+// computed from. Ingesting the same text again replaces the earlier copy.
+func (s *KeywordService) IngestJobDescription(ctx context.Context, text string) (*models.KeywordVector, error) {
+	opts := DefaultKeywordOptions()
+	opts.Limit = 0
+	embedding, embeddingModel, err := s.embedText(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &models.KeywordVector{
+		SourceType:     models.KeywordSourceJob,
+		SourceID:       documentID(text),
+		Text:           text,
+		Keywords:       s.ExtractKeywords(text, opts),
+		Embedding:      embedding,
+		EmbeddingModel: embeddingModel,
+	}
+	if err := s.keywordRepo.UpsertDocument(ctx, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// CorpusStats returns the totals of the corpus of job descriptions
+func (s *KeywordService) CorpusStats(ctx context.Context) (*models.KeywordCorpusStats, error) {
+	return s.keywordRepo.GetCorpusStats(ctx)
+}
+
+// recomputeBatchSize is how many documents RecomputeStatistics re-extracts at a time
+const recomputeBatchSize = 500
+
+// RecomputeStatistics re-extracts the keywords of the job descriptions of the corpus, so that
+// changes to the extractor such as new skill aliases take effect, and rebuilds the document
+// frequencies from them
+func (s *KeywordService) RecomputeStatistics(ctx context.Context) (*models.KeywordCorpusStats, error) {
+	opts := DefaultKeywordOptions()
+	opts.Limit = 0
+
+	for afterID := 0; ; {
+		docs, err := s.keywordRepo.ListDocuments(ctx, models.KeywordSourceJob, afterID, recomputeBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) == 0 {
+			break
+		}
+		for _, doc := range docs {
+			if err := s.keywordRepo.UpdateKeywords(ctx, doc.ID, s.ExtractKeywords(doc.Text, opts)); err != nil {
+				return nil, err
+			}
+		}
+		afterID = docs[len(docs)-1].ID
+	}
+	return s.keywordRepo.RecomputeStatistics(ctx)
+}
+
+// documentID identifies a text by the hash of its normalized content, so that resubmitting
+// a posting with different whitespace or case does not count it twice
+func documentID(text string) string {
+	sum := sha256.Sum256([]byte(cleanText(strings.ToLower(text))))
+	return hex.EncodeToString(sum[:])
+}
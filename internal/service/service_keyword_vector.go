@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// ErrEmptyEmbedding is returned when a job description has nothing to embed, e.g. no keywords,
+// so that it cannot be compared with resumes
+var ErrEmptyEmbedding = errors.New("job description has no content to compare with resumes")
+
+// defaultSimilarResumes is how many resumes a similarity search returns unless asked otherwise
+const defaultSimilarResumes = 5
+
+// SimilarResume is a resume version ranked by how closely it matches a job description
+type SimilarResume struct {
+	Resume     models.Resume    `json:"resume"`
+	Similarity float64          `json:"similarity"` // Cosine similarity of the embeddings, from -1 to 1
+	Keywords   []models.Keyword `json:"keywords"`   // Top keywords of the resume
+}
+
+// embedText returns the vector of a text together with the embedder that produced it. The
+// vector is nil when it is all zeros, e.g. for a text without keywords: a zero vector has no
+// direction, so its cosine similarity is undefined, and it is stored as NULL.
+func (s *KeywordService) embedText(ctx context.Context, text string) (models.Vector, string, error) {
+	embedding, err := s.embedder.Embed(ctx, text)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to embed text: %v", err)
+	}
+	if isZeroVector(embedding) {
+		return nil, s.embedder.Name(), nil
+	}
+	return embedding, s.embedder.Name(), nil
+}
+
+// IndexResume stores the keyword vector of a resume version
+func (s *KeywordService) IndexResume(ctx context.Context, resume *models.Resume) error {
+	text := resumeText(resume)
+	embedding, embeddingModel, err := s.embedText(ctx, text)
+	if err != nil {
+		return err
+	}
+	opts := DefaultKeywordOptions()
+	opts.Limit = 0
+
+	return s.keywordRepo.UpsertDocument(ctx, &models.KeywordVector{
+		SourceType:     models.KeywordSourceResume,
+		SourceID:       resumeSourceID(resume.ID),
+		Text:           text,
+		Keywords:       s.ExtractKeywords(text, opts),
+		Embedding:      embedding,
+		EmbeddingModel: embeddingModel,
+	})
+}
+
+// RemoveResume deletes the keyword vector of a resume version
+func (s *KeywordService) RemoveResume(ctx context.Context, resumeID uint) error {
+	return s.keywordRepo.DeleteDocument(ctx, models.KeywordSourceResume, resumeSourceID(resumeID))
+}
+
+// SimilarResumes returns the resume versions of a user whose embeddings are closest to that of
+// a job description. Resumes that are not indexed yet, whose content changed since they were
+// indexed, or that were embedded by another embedder are indexed first. It returns
+// ErrEmptyEmbedding when the job description embeds to a zero vector.
+func (s *ResumeService) SimilarResumes(ctx context.Context, userID uint, jobDescription string, limit int) ([]SimilarResume, error) {
+	if limit <= 0 {
+		limit = defaultSimilarResumes
+	}
+
+	resumes, err := s.resumeRepo.GetResumesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.Resume, len(resumes))
+	sourceIDs := make([]string, 0, len(resumes))
+	for i := range resumes {
+		id := resumeSourceID(resumes[i].ID)
+		byID[id] = &resumes[i]
+		sourceIDs = append(sourceIDs, id)
+	}
+
+	if err := s.refreshResumeIndex(ctx, resumes, sourceIDs); err != nil {
+		return nil, err
+	}
+
+	embedding, embeddingModel, err := s.keywordService.embedText(ctx, jobDescription)
+	if err != nil {
+		return nil, err
+	}
+	if embedding == nil {
+		return nil, ErrEmptyEmbedding
+	}
+	results, err := s.keywordService.keywordRepo.FindNearest(ctx, embedding, embeddingModel, models.KeywordSourceResume, sourceIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	similar := make([]SimilarResume, 0, len(results))
+	for _, result := range results {
+		resume, ok := byID[result.SourceID]
+		if !ok {
+			continue
+		}
+		similar = append(similar, SimilarResume{
+			Resume:     *resume,
+			Similarity: roundTo(result.Similarity, 4),
+			Keywords:   sortKeywords(result.Keywords, 10),
+		})
+	}
+	return similar, nil
+}
+
+// refreshResumeIndex indexes the resumes whose stored vector is missing or stale. Resumes
+// without keywords stay indexed without a vector.
+func (s *ResumeService) refreshResumeIndex(ctx context.Context, resumes []models.Resume, sourceIDs []string) error {
+	docs, err := s.keywordService.keywordRepo.GetDocuments(ctx, models.KeywordSourceResume, sourceIDs)
+	if err != nil {
+		return err
+	}
+	indexed := make(map[string]string, len(docs))
+	for _, doc := range docs {
+		if doc.EmbeddingModel == s.keywordService.embedder.Name() {
+			indexed[doc.SourceID] = doc.Text
+		}
+	}
+
+	for i := range resumes {
+		text, ok := indexed[resumeSourceID(resumes[i].ID)]
+		if ok && text == resumeText(&resumes[i]) {
+			continue
+		}
+		if err := s.keywordService.IndexResume(ctx, &resumes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeResumeIndex deletes the keyword vector of a deleted resume; failures only leave an
+// orphaned vector behind, which similarity searches ignore
+func (s *ResumeService) removeResumeIndex(ctx context.Context, resumeID uint) {
+	if err := s.keywordService.RemoveResume(ctx, resumeID); err != nil {
+		log.Printf("Failed to remove keyword vector of resume %d: %v", resumeID, err)
+	}
+}
+
+// resumeSourceID returns the source ID of a resume in keyword_vectors
+func resumeSourceID(resumeID uint) string {
+	return strconv.FormatUint(uint64(resumeID), 10)
+}
+
+// resumeText returns the text of a resume version for keyword extraction. Structured resumes
+// are reduced to their values so that JSON field names do not count as keywords.
+func resumeText(resume *models.Resume) string {
+	if resume.Format != models.ResumeFormatJSON {
+		return resume.Content
+	}
+
+	var content models.ResumeContent
+	if err := json.Unmarshal([]byte(resume.Content), &content); err != nil {
+		return resume.Content
+	}
+
+	parts := []string{content.Summary}
+	for _, exp := range content.Experience {
+		parts = append(parts, exp.Title, exp.Company)
+		parts = append(parts, exp.Description...)
+	}
+	for _, edu := range content.Education {
+		parts = append(parts, edu.Degree, edu.Field, edu.School, edu.Description)
+	}
+	parts = append(parts, strings.Join(content.Skills, ", "))
+	for _, project := range content.Projects {
+		parts = append(parts, project.Name, project.Description, strings.Join(project.Technologies, ", "))
+	}
+	return strings.Join(parts, "\n")
+}
@@ -1,176 +1,216 @@
 package service
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/llm/fixture"
 )
 
-// LLMService provides functionality to interact with LLM models
-type LLMService struct {
-	client  *http.Client
-	baseURL string
+// LLMProvider generates text with an LLM backend
+type LLMProvider interface {
+	// GenerateContent sends a request to the model and returns the complete generated text
+	GenerateContent(ctx context.Context, req GenerationRequest) (string, error)
+	// StreamGenerateContent streams the generated text to handler as it is produced
+	StreamGenerateContent(ctx context.Context, req GenerationRequest, handler StreamHandler) error
+	// ListModels returns the models available on the backend
+	ListModels(ctx context.Context) ([]ModelInfo, error)
 }
 
-// NewLLMService creates a new LLMService instance
-func NewLLMService() *LLMService {
-	return &LLMService{
-		client: &http.Client{
-			Timeout: 120 * time.Second, // Set a reasonable timeout for LLM requests
-		},
-		baseURL: "http://localhost:11434/api", // Local Deepseek/Ollama instance
-	}
+// ModelInfo describes a model available on the LLM backend
+type ModelInfo struct {
+	Name          string     `json:"name"`
+	Size          int64      `json:"size,omitempty"` // Bytes on disk, when reported by the backend
+	Family        string     `json:"family,omitempty"`
+	ParameterSize string     `json:"parameterSize,omitempty"`
+	ModifiedAt    *time.Time `json:"modifiedAt,omitempty"`
 }
 
-// LLMRequest represents a request to the LLM API
-type LLMRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+// LLMAPIError is returned when the LLM backend answers with an unsuccessful status
+type LLMAPIError struct {
+	StatusCode int
+	Message    string // Error message of the backend, or its raw response body
 }
 
-// LLMResponse represents a response from the LLM API (Ollama format)
-type LLMResponse struct {
-	Model     string `json:"model"`
-	CreatedAt string `json:"created_at"`
-	Response  string `json:"response"`
-	Done      bool   `json:"done"`
+func (e *LLMAPIError) Error() string {
+	return fmt.Sprintf("LLM API request failed with status %d: %s", e.StatusCode, e.Message)
 }
 
-// GenerateContent sends a prompt to the LLM model and returns the generated text
-func (s *LLMService) GenerateContent(ctx context.Context, model string, prompt string) (string, error) {
-	// Create request
-	reqBody := LLMRequest{
-		Model:  model,
-		Prompt: prompt,
-		Stream: false, // Not streaming for this method
-	}
+// GenerationRequest describes a single generation independently of the provider
+type GenerationRequest struct {
+	Model  string
+	Prompt string // Sent as the last user message when System or Messages are set
+	JSON   bool   // Constrain the output to a JSON object
 
-	// Convert request to JSON
-	reqJSON, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", err
-	}
+	System   string        // Instructions sent as a system message
+	Messages []ChatMessage // Earlier turns of the conversation, sent before Prompt
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/generate", bytes.NewBuffer(reqJSON))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Content-Type", "application/json")
+	Options *GenerationOptions // Sampling parameters; nil keeps the model defaults
 
-	// Send request
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+	UserID          uint               // User the generation is for, used to queue calls fairly
+	OnQueuePosition func(position int) // Called with the queue position while the call waits for the model
+	Background      bool               // Call of a background job, which waits for the model even when the queue is full
+}
 
-	// Check for successful status code
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", errors.New("LLM API request failed with status: " + resp.Status + ", body: " + string(body))
-	}
+// Roles of chat messages
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
 
-	// Read and process the response
-	responseBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
+// ChatMessage is a single message of a chat conversation
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
 
-	// Process the response
-	responseJson := string(responseBytes)
+// isChat reports whether the request has role-separated messages rather than a single prompt
+func (req GenerationRequest) isChat() bool {
+	return req.System != "" || len(req.Messages) > 0
+}
 
-	// Parse the response
-	var llmResponse LLMResponse
-	if err := json.Unmarshal(responseBytes, &llmResponse); err != nil {
-		return "", errors.New("Failed to parse LLM response: " + err.Error() + ", response: " + responseJson)
+// chatMessages returns the request as a conversation: the system message, earlier turns and the prompt
+func (req GenerationRequest) chatMessages() []ChatMessage {
+	messages := make([]ChatMessage, 0, len(req.Messages)+2)
+	if req.System != "" {
+		messages = append(messages, ChatMessage{Role: RoleSystem, Content: req.System})
 	}
-
-	return llmResponse.Response, nil
+	messages = append(messages, req.Messages...)
+	if req.Prompt != "" {
+		messages = append(messages, ChatMessage{Role: RoleUser, Content: req.Prompt})
+	}
+	return messages
 }
 
+// errLLMStreamIncomplete is returned when a stream closes before the model signalled completion
+var errLLMStreamIncomplete = errors.New("LLM stream ended before completion")
+
 // StreamHandler represents a function that handles streaming response chunks
 type StreamHandler func(chunk string, done bool) error
 
-// StreamGenerateContent streams the LLM responses as they are generated
-func (s *LLMService) StreamGenerateContent(ctx context.Context, model string, prompt string, handler StreamHandler) error {
-	// Create request with streaming enabled
-	reqBody := LLMRequest{
-		Model:  model,
-		Prompt: prompt,
-		Stream: true,
+// NewLLMProvider creates the LLM provider selected by the configuration
+func NewLLMProvider(cfg *config.LLMConfig) (LLMProvider, error) {
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+	}
+
+	switch cfg.FixtureMode {
+	case "":
+	case config.LLMFixtureRecord:
+		client.Transport = fixture.NewRecordingTransport(http.DefaultTransport, cfg.FixtureDir)
+	case config.LLMFixtureReplay:
+		client.Transport = fixture.NewReplayTransport(cfg.FixtureDir)
+	default:
+		return nil, fmt.Errorf("unsupported LLM fixture mode %q", cfg.FixtureMode)
+	}
+
+	var provider LLMProvider
+	switch cfg.Provider {
+	case config.LLMProviderOllama:
+		provider = NewOllamaProvider(client, cfg.BaseURL)
+	case config.LLMProviderOpenAI:
+		provider = NewOpenAIProvider(client, cfg.BaseURL, cfg.APIKey)
+	case config.LLMProviderFake:
+		fake := NewFakeLLMProvider(cfg.FakeLatency)
+		// Every configured model is "installed" so that generations are not refused
+		for _, model := range append([]string{cfg.Model}, cfg.AllowedModels...) {
+			fake.Models = append(fake.Models, ModelInfo{Name: model})
+		}
+		provider = fake
+	default:
+		return nil, fmt.Errorf("unsupported LLM provider %q", cfg.Provider)
 	}
 
+	// A single model instance only serves a few generations at once
+	return NewLimitedLLMProvider(provider, NewLLMLimiter(cfg.MaxConcurrency, cfg.MaxQueueDepth)), nil
+}
+
+// postJSON sends a JSON request to an LLM API and returns the response when it succeeded
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, body interface{}) (*http.Response, error) {
 	// Convert request to JSON
-	reqJSON, err := json.Marshal(reqBody)
+	reqJSON, err := json.Marshal(body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/generate", bytes.NewBuffer(reqJSON))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqJSON))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
 
 	// Send request
-	resp, err := s.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
 	// Check for successful status code
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return errors.New("LLM API request failed with status: " + resp.Status + ", body: " + string(body))
-	}
-
-	// Process the streaming response
-	reader := bufio.NewReader(resp.Body)
-	var fullResponse string
-
-	for {
-		// Read line by line (each line is a JSON object)
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return err
-		}
+		defer resp.Body.Close()
+		return nil, newLLMAPIError(resp)
+	}
 
-		// Skip empty lines
-		if len(line) == 0 || (len(line) == 1 && line[0] == '\n') {
-			continue
-		}
+	return resp, nil
+}
 
-		// Parse the JSON response
-		var llmResponse LLMResponse
-		if err := json.Unmarshal(line, &llmResponse); err != nil {
-			return errors.New("Failed to parse LLM response chunk: " + err.Error())
-		}
+// getJSON sends a GET request to an LLM API and decodes the JSON response into out
+func getJSON(ctx context.Context, client *http.Client, url, apiKey string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
 
-		// Append to full response
-		fullResponse += llmResponse.Response
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-		// Send the chunk to the handler
-		if err := handler(llmResponse.Response, llmResponse.Done); err != nil {
-			return err
-		}
+	if resp.StatusCode != http.StatusOK {
+		return newLLMAPIError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.New("Failed to parse LLM response: " + err.Error())
+	}
+	return nil
+}
 
-		// If this is the last chunk, we're done
-		if llmResponse.Done {
-			break
-		}
+// newLLMAPIError reads the error message of an unsuccessful response. Ollama sends
+// {"error":"..."}, OpenAI-compatible servers {"error":{"message":"..."}}.
+func newLLMAPIError(resp *http.Response) *LLMAPIError {
+	body, _ := io.ReadAll(resp.Body)
+	message := strings.TrimSpace(string(body))
+
+	var ollamaError struct {
+		Error string `json:"error"`
+	}
+	var openAIError struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &ollamaError) == nil && ollamaError.Error != "" {
+		message = ollamaError.Error
+	} else if json.Unmarshal(body, &openAIError) == nil && openAIError.Error.Message != "" {
+		message = openAIError.Error.Message
 	}
 
-	return nil
+	return &LLMAPIError{StatusCode: resp.StatusCode, Message: message}
 }
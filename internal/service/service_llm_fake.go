@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrFakeLLMFailure is the error FakeLLMProvider returns for injected mid-stream failures
+var ErrFakeLLMFailure = errors.New("fake LLM failure")
+
+// FakeLLMProvider is an in-process LLMProvider producing deterministic output without a model.
+// It is meant for development and tests; the zero value is ready to use.
+type FakeLLMProvider struct {
+	Response  string        // Text returned for every request; derived from the prompt when empty
+	ChunkSize int           // Words per streamed chunk, 1 when zero
+	Latency   time.Duration // Delay before each streamed chunk and before non-streamed responses
+	Err       error         // Returned before any output when set
+	FailAfter int           // Fail with ErrFakeLLMFailure after this many chunks when greater than zero
+	Models    []ModelInfo   // Models returned by ListModels; a single "fake" model when empty
+
+	mu       sync.Mutex
+	requests []GenerationRequest
+}
+
+// NewFakeLLMProvider creates a fake provider that waits latency before each chunk
+func NewFakeLLMProvider(latency time.Duration) *FakeLLMProvider {
+	return &FakeLLMProvider{Latency: latency}
+}
+
+// Requests returns the requests received so far
+func (p *FakeLLMProvider) Requests() []GenerationRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]GenerationRequest(nil), p.requests...)
+}
+
+// GenerateContent returns the complete fake response
+func (p *FakeLLMProvider) GenerateContent(ctx context.Context, req GenerationRequest) (string, error) {
+	p.record(req)
+	if p.Err != nil {
+		return "", p.Err
+	}
+	if err := sleepContext(ctx, p.Latency); err != nil {
+		return "", err
+	}
+	return p.response(req), nil
+}
+
+// StreamGenerateContent streams the fake response in chunks of ChunkSize words
+func (p *FakeLLMProvider) StreamGenerateContent(ctx context.Context, req GenerationRequest, handler StreamHandler) error {
+	p.record(req)
+	if p.Err != nil {
+		return p.Err
+	}
+
+	chunks := SplitChunks(p.response(req), p.ChunkSize)
+	for i, chunk := range chunks {
+		if p.FailAfter > 0 && i == p.FailAfter {
+			return ErrFakeLLMFailure
+		}
+		if err := sleepContext(ctx, p.Latency); err != nil {
+			return err
+		}
+		if err := handler(chunk, false); err != nil {
+			return err
+		}
+	}
+	return handler("", true)
+}
+
+// ListModels returns the configured models
+func (p *FakeLLMProvider) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	if len(p.Models) == 0 {
+		return []ModelInfo{{Name: "fake"}}, nil
+	}
+	return append([]ModelInfo(nil), p.Models...), nil
+}
+
+func (p *FakeLLMProvider) record(req GenerationRequest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+}
+
+// response returns the configured response or one derived deterministically from the prompt
+func (p *FakeLLMProvider) response(req GenerationRequest) string {
+	if p.Response != "" {
+		return p.Response
+	}
+
+	sum := sha256.Sum256([]byte(req.Prompt))
+	fingerprint := hex.EncodeToString(sum[:4])
+	if req.JSON {
+		return fmt.Sprintf(`{"summary": "Fake resume %s generated by %s.", "experience": [], "education": [], "skills": [], "projects": []}`, fingerprint, req.Model)
+	}
+	return fmt.Sprintf("# Fake Resume\n\nThis resume %s was generated by %s without a model.\n\n## Experience\n\n- Delivered deterministic output for development and tests.\n", fingerprint, req.Model)
+}
+
+// SplitChunks splits text into chunks of size words, keeping the whitespace so that
+// concatenating the chunks yields the original text
+func SplitChunks(text string, size int) []string {
+	if size <= 0 {
+		size = 1
+	}
+
+	words := strings.SplitAfter(text, " ")
+	chunks := make([]string, 0, len(words)/size+1)
+	for i := 0; i < len(words); i += size {
+		end := i + size
+		if end > len(words) {
+			end = len(words)
+		}
+		chunks = append(chunks, strings.Join(words[i:end], ""))
+	}
+	return chunks
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
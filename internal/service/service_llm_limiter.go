@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrLLMQueueFull is returned when too many LLM calls are already waiting for a slot
+var ErrLLMQueueFull = errors.New("too many generations are waiting for the model, try again later")
+
+// LLMLimiter bounds the number of concurrent LLM calls. Waiting calls are queued per user and
+// served round-robin across users, so that one user submitting many generations cannot starve
+// the others. Calls of background jobs wait without counting against the queue depth, since
+// the job queue bounds them already.
+type LLMLimiter struct {
+	maxConcurrency int
+	maxQueueDepth  int
+
+	mu      sync.Mutex
+	active  int
+	waiting int // Waiting calls counted against maxQueueDepth
+	queues  map[uint][]*llmWaiter
+	order   []uint // Users with waiting calls; the first is served next
+}
+
+// llmWaiter is a call waiting for a slot
+type llmWaiter struct {
+	ready      chan struct{} // Closed when the call is granted a slot
+	granted    bool
+	background bool // Not counted against the queue depth
+	onPosition func(position int)
+	position   int
+}
+
+// NewLLMLimiter creates a limiter running at most maxConcurrency calls at once with at most
+// maxQueueDepth calls waiting
+func NewLLMLimiter(maxConcurrency, maxQueueDepth int) *LLMLimiter {
+	return &LLMLimiter{
+		maxConcurrency: maxConcurrency,
+		maxQueueDepth:  maxQueueDepth,
+		queues:         make(map[uint][]*llmWaiter),
+	}
+}
+
+// Acquire waits for a slot for a call of userID and returns the function releasing it.
+// onPosition, when not nil, is called with the 1-based queue position whenever it changes.
+// Background calls are never rejected with ErrLLMQueueFull.
+func (l *LLMLimiter) Acquire(ctx context.Context, userID uint, background bool, onPosition func(position int)) (func(), error) {
+	l.mu.Lock()
+	if l.active < l.maxConcurrency && len(l.order) == 0 {
+		l.active++
+		l.mu.Unlock()
+		return l.release, nil
+	}
+	if !background && l.waiting >= l.maxQueueDepth {
+		l.mu.Unlock()
+		return nil, ErrLLMQueueFull
+	}
+
+	w := &llmWaiter{ready: make(chan struct{}), background: background, onPosition: onPosition}
+	if len(l.queues[userID]) == 0 {
+		l.order = append(l.order, userID)
+	}
+	l.queues[userID] = append(l.queues[userID], w)
+	if !background {
+		l.waiting++
+	}
+	updates := l.positionsLocked()
+	l.mu.Unlock()
+	notifyPositions(updates)
+
+	select {
+	case <-w.ready:
+		return l.release, nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	if w.granted {
+		// Granted while the context was canceled; hand the slot to the next call
+		l.mu.Unlock()
+		l.release()
+		return nil, ctx.Err()
+	}
+	l.removeLocked(userID, w)
+	updates = l.positionsLocked()
+	l.mu.Unlock()
+	notifyPositions(updates)
+	return nil, ctx.Err()
+}
+
+// release frees a slot and grants it to the next waiting call
+func (l *LLMLimiter) release() {
+	l.mu.Lock()
+	l.active--
+	for l.active < l.maxConcurrency && len(l.order) > 0 {
+		userID := l.order[0]
+		queue := l.queues[userID]
+		w := queue[0]
+
+		l.order = l.order[1:]
+		if len(queue) > 1 {
+			l.queues[userID] = queue[1:]
+			l.order = append(l.order, userID)
+		} else {
+			delete(l.queues, userID)
+		}
+		if !w.background {
+			l.waiting--
+		}
+		l.active++
+		w.granted = true
+		close(w.ready)
+	}
+	updates := l.positionsLocked()
+	l.mu.Unlock()
+	notifyPositions(updates)
+}
+
+// removeLocked drops a waiting call of a user from the queue
+func (l *LLMLimiter) removeLocked(userID uint, w *llmWaiter) {
+	queue := l.queues[userID]
+	for i, queued := range queue {
+		if queued == w {
+			queue = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if !w.background {
+		l.waiting--
+	}
+	if len(queue) > 0 {
+		l.queues[userID] = queue
+		return
+	}
+	delete(l.queues, userID)
+	for i, id := range l.order {
+		if id == userID {
+			l.order = append(l.order[:i:i], l.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// positionUpdate is a queue position to report to a waiting call
+type positionUpdate struct {
+	onPosition func(position int)
+	position   int
+}
+
+// positionsLocked recomputes the queue positions in round-robin order and returns the ones
+// that changed. The n-th call of a user is served after the first n calls of the users before
+// it in the rotation and the first n-1 calls of the users after it.
+func (l *LLMLimiter) positionsLocked() []positionUpdate {
+	var updates []positionUpdate
+	for k, userID := range l.order {
+		for i, w := range l.queues[userID] {
+			ahead := i
+			for j, otherID := range l.order {
+				if j == k {
+					continue
+				}
+				served := i
+				if j < k {
+					served = i + 1
+				}
+				ahead += min(len(l.queues[otherID]), served)
+			}
+			if position := ahead + 1; position != w.position {
+				w.position = position
+				if w.onPosition != nil {
+					updates = append(updates, positionUpdate{onPosition: w.onPosition, position: position})
+				}
+			}
+		}
+	}
+	return updates
+}
+
+// notifyPositions reports queue positions outside of the limiter's lock
+func notifyPositions(updates []positionUpdate) {
+	for _, u := range updates {
+		u.onPosition(u.position)
+	}
+}
+
+// LimitedLLMProvider passes calls to a provider through an LLMLimiter
+type LimitedLLMProvider struct {
+	provider LLMProvider
+	limiter  *LLMLimiter
+}
+
+// NewLimitedLLMProvider wraps a provider with a limiter
+func NewLimitedLLMProvider(provider LLMProvider, limiter *LLMLimiter) *LimitedLLMProvider {
+	return &LimitedLLMProvider{provider: provider, limiter: limiter}
+}
+
+// GenerateContent waits for a slot and generates the complete text
+func (p *LimitedLLMProvider) GenerateContent(ctx context.Context, req GenerationRequest) (string, error) {
+	release, err := p.limiter.Acquire(ctx, req.UserID, req.Background, req.OnQueuePosition)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	return p.provider.GenerateContent(ctx, req)
+}
+
+// StreamGenerateContent waits for a slot and streams the generated text
+func (p *LimitedLLMProvider) StreamGenerateContent(ctx context.Context, req GenerationRequest, handler StreamHandler) error {
+	release, err := p.limiter.Acquire(ctx, req.UserID, req.Background, req.OnQueuePosition)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return p.provider.StreamGenerateContent(ctx, req, handler)
+}
+
+// ListModels lists the models of the provider without waiting for a slot
+func (p *LimitedLLMProvider) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	return p.provider.ListModels(ctx)
+}
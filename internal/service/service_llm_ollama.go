@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// OllamaProvider talks to Ollama's native /api/generate and /api/chat endpoints
+type OllamaProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewOllamaProvider creates a provider for the Ollama API at baseURL, e.g. http://localhost:11434/api
+func NewOllamaProvider(client *http.Client, baseURL string) *OllamaProvider {
+	return &OllamaProvider{
+		client:  client,
+		baseURL: baseURL,
+	}
+}
+
+// LLMRequest represents a request to the LLM API (Ollama format)
+type LLMRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+	Format string `json:"format,omitempty"` // "json" constrains the output to a JSON object
+
+	Options map[string]interface{} `json:"options,omitempty"` // Sampling parameters such as temperature
+}
+
+// LLMChatRequest represents a request to the chat endpoint of the LLM API (Ollama format)
+type LLMChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Format   string        `json:"format,omitempty"`
+
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// LLMResponse represents a response from the LLM API (Ollama format). The generate endpoint
+// returns the text in Response, the chat endpoint in Message.
+type LLMResponse struct {
+	Model     string       `json:"model"`
+	CreatedAt string       `json:"created_at"`
+	Response  string       `json:"response"`
+	Message   *ChatMessage `json:"message,omitempty"`
+	Done      bool         `json:"done"`
+}
+
+// text returns the generated text of the response
+func (r LLMResponse) text() string {
+	if r.Message != nil {
+		return r.Message.Content
+	}
+	return r.Response
+}
+
+// newLLMRequest converts a generation request to the Ollama wire format
+func newLLMRequest(req GenerationRequest, stream bool) LLMRequest {
+	llmRequest := LLMRequest{
+		Model:  req.Model,
+		Prompt: req.Prompt,
+		Stream: stream,
+
+		Options: req.Options.ollamaOptions(),
+	}
+	if req.JSON {
+		llmRequest.Format = "json"
+	}
+	return llmRequest
+}
+
+// newOllamaCall returns the endpoint and body of a request: /chat for requests with
+// role-separated messages, /generate for a single prompt
+func newOllamaCall(req GenerationRequest, stream bool) (string, interface{}) {
+	if !req.isChat() {
+		return "/generate", newLLMRequest(req, stream)
+	}
+
+	chatRequest := LLMChatRequest{
+		Model:    req.Model,
+		Messages: req.chatMessages(),
+		Stream:   stream,
+
+		Options: req.Options.ollamaOptions(),
+	}
+	if req.JSON {
+		chatRequest.Format = "json"
+	}
+	return "/chat", chatRequest
+}
+
+// GenerateContent sends a prompt to the LLM model and returns the generated text
+func (p *OllamaProvider) GenerateContent(ctx context.Context, req GenerationRequest) (string, error) {
+	path, body := newOllamaCall(req, false)
+	resp, err := postJSON(ctx, p.client, p.baseURL+path, "", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Read and process the response
+	responseBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// Parse the response
+	var llmResponse LLMResponse
+	if err := json.Unmarshal(responseBytes, &llmResponse); err != nil {
+		return "", errors.New("Failed to parse LLM response: " + err.Error() + ", response: " + string(responseBytes))
+	}
+
+	return llmResponse.text(), nil
+}
+
+// StreamGenerateContent streams the LLM responses as they are generated
+func (p *OllamaProvider) StreamGenerateContent(ctx context.Context, req GenerationRequest, handler StreamHandler) error {
+	path, body := newOllamaCall(req, true)
+	resp, err := postJSON(ctx, p.client, p.baseURL+path, "", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Process the streaming response
+	reader := bufio.NewReader(resp.Body)
+
+	for {
+		// Read line by line (each line is a JSON object)
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		// Skip empty lines
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			// Parse the JSON response
+			var llmResponse LLMResponse
+			if err := json.Unmarshal(line, &llmResponse); err != nil {
+				return errors.New("Failed to parse LLM response chunk: " + err.Error())
+			}
+
+			// Send the chunk to the handler
+			if err := handler(llmResponse.text(), llmResponse.Done); err != nil {
+				return err
+			}
+
+			// If this is the last chunk, we're done
+			if llmResponse.Done {
+				return nil
+			}
+		}
+
+		if readErr == io.EOF {
+			return errLLMStreamIncomplete
+		}
+	}
+}
+
+// ollamaTagsResponse is the response of Ollama's /api/tags endpoint
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name       string    `json:"name"`
+		Size       int64     `json:"size"`
+		ModifiedAt time.Time `json:"modified_at"`
+		Details    struct {
+			Family        string `json:"family"`
+			ParameterSize string `json:"parameter_size"`
+		} `json:"details"`
+	} `json:"models"`
+}
+
+// ListModels returns the locally installed models
+func (p *OllamaProvider) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var tags ollamaTagsResponse
+	if err := getJSON(ctx, p.client, p.baseURL+"/tags", "", &tags); err != nil {
+		return nil, err
+	}
+
+	models := make([]ModelInfo, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		modifiedAt := m.ModifiedAt
+		models = append(models, ModelInfo{
+			Name:          m.Name,
+			Size:          m.Size,
+			Family:        m.Details.Family,
+			ParameterSize: m.Details.ParameterSize,
+			ModifiedAt:    &modifiedAt,
+		})
+	}
+	return models, nil
+}
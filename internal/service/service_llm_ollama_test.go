@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/llm/fixture"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repotest"
+)
+
+// ollamaFixtureDir holds the recorded Ollama exchanges replayed by the tests
+const ollamaFixtureDir = "testdata/llm"
+
+// recordOllama re-records the fixtures against a real Ollama API, e.g.
+// go test ./internal/service -run Ollama -record http://localhost:11434/api
+var recordOllama = flag.String("record", "", "record the Ollama fixtures against the Ollama API at this URL")
+
+// newFixtureOllamaProvider returns an Ollama provider replaying the recorded fixtures, or
+// recording them when -record is set
+func newFixtureOllamaProvider() *OllamaProvider {
+	if *recordOllama != "" {
+		client := &http.Client{Transport: fixture.NewRecordingTransport(nil, ollamaFixtureDir)}
+		return NewOllamaProvider(client, *recordOllama)
+	}
+	client := &http.Client{Transport: fixture.NewReplayTransport(ollamaFixtureDir)}
+	return NewOllamaProvider(client, "http://ollama.invalid/api")
+}
+
+// fixtureRequest returns the generation request the fixtures were recorded with, for the
+// profile of repotest.User
+func fixtureRequest(jobDescription string) GenerationRequest {
+	system, prompt := (&ResumeService{}).buildPrompt(
+		[]string{"go", "kubernetes", "microservices"},
+		jobDescription,
+		repotest.User(),
+	)
+	return GenerationRequest{Model: "llama3.2", System: system, Prompt: prompt}
+}
+
+func TestOllamaStreamReplay(t *testing.T) {
+	provider := newFixtureOllamaProvider()
+
+	var chunks []string
+	var content strings.Builder
+	doneCalls := 0
+	err := provider.StreamGenerateContent(context.Background(), fixtureRequest(repotest.JobDescription), func(chunk string, done bool) error {
+		if doneCalls > 0 {
+			t.Errorf("chunk %q after the done message", chunk)
+		}
+		if done {
+			doneCalls++
+		}
+		chunks = append(chunks, chunk)
+		content.WriteString(chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamGenerateContent: %v", err)
+	}
+	if *recordOllama != "" {
+		t.Skip("recorded a new fixture; its content depends on the model")
+	}
+
+	if doneCalls != 1 {
+		t.Errorf("got %d done messages, want 1", doneCalls)
+	}
+	if len(chunks) < 10 {
+		t.Errorf("got %d chunks, want the response streamed in many chunks", len(chunks))
+	}
+	if !strings.HasPrefix(content.String(), "# Jane Doe\n") {
+		t.Errorf("content does not start with the name heading:\n%s", content.String())
+	}
+
+	output, provenance := extractProvenance(content.String(), repotest.User())
+	if strings.Contains(output, "[src:") {
+		t.Errorf("source markers left in the output:\n%s", output)
+	}
+	if len(provenance) != 3 {
+		t.Fatalf("got %d bullet points with provenance, want 3: %+v", len(provenance), provenance)
+	}
+	want := []models.SourceRef{
+		{Kind: models.SourceWorkExperience, ID: 12},
+		{Kind: models.SourceWorkExperience, ID: 12},
+		{Kind: models.SourceEducation, ID: 3},
+	}
+	for i, bullet := range provenance {
+		if len(bullet.Invalid) > 0 {
+			t.Errorf("bullet %d has invalid references %v", i, bullet.Invalid)
+		}
+		if len(bullet.Sources) != 1 || bullet.Sources[0].Kind != want[i].Kind || bullet.Sources[0].ID != want[i].ID {
+			t.Errorf("bullet %d has sources %+v, want %s %d", i, bullet.Sources, want[i].Kind, want[i].ID)
+		}
+	}
+}
+
+func TestOllamaReplayRejectsChangedPrompt(t *testing.T) {
+	if *recordOllama != "" {
+		t.Skip("only meaningful when replaying")
+	}
+	provider := newFixtureOllamaProvider()
+
+	req := fixtureRequest(repotest.JobDescription + " Remote work is possible.")
+	err := provider.StreamGenerateContent(context.Background(), req, func(string, bool) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "no fixture") {
+		t.Fatalf("got error %v, want a missing fixture for a changed prompt", err)
+	}
+}
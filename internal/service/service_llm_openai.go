@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider talks to OpenAI-compatible /chat/completions endpoints such as vLLM gateways
+type OpenAIProvider struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewOpenAIProvider creates a provider for the OpenAI-compatible API at baseURL, e.g. http://localhost:8000/v1
+func NewOpenAIProvider(client *http.Client, baseURL, apiKey string) *OpenAIProvider {
+	return &OpenAIProvider{
+		client:  client,
+		baseURL: baseURL,
+		apiKey:  apiKey,
+	}
+}
+
+// ChatCompletionRequest represents a request to the chat completions API (OpenAI format)
+type ChatCompletionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []ChatMessage   `json:"messages"`
+	Stream         bool            `json:"stream"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// ResponseFormat constrains the completion output (OpenAI format)
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
+// ChatCompletionResponse represents a complete or streamed chat completion (OpenAI format)
+type ChatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+}
+
+// ChatCompletionChoice holds the message of a completion or the delta of a streamed chunk
+type ChatCompletionChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	Delta        ChatMessage `json:"delta"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+// newChatCompletionRequest converts a generation request to the OpenAI wire format
+func newChatCompletionRequest(req GenerationRequest, stream bool) ChatCompletionRequest {
+	completionRequest := ChatCompletionRequest{
+		Model:    req.Model,
+		Messages: req.chatMessages(),
+		Stream:   stream,
+	}
+	if req.JSON {
+		completionRequest.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	}
+	// The context size is fixed when an OpenAI-compatible server loads the model, so num_ctx is not sent
+	if opts := req.Options; opts != nil {
+		completionRequest.Temperature = opts.Temperature
+		completionRequest.TopP = opts.TopP
+		completionRequest.Seed = opts.Seed
+		completionRequest.Stop = opts.Stop
+	}
+	return completionRequest
+}
+
+// GenerateContent sends a prompt to the LLM model and returns the generated text
+func (p *OpenAIProvider) GenerateContent(ctx context.Context, req GenerationRequest) (string, error) {
+	resp, err := postJSON(ctx, p.client, p.baseURL+"/chat/completions", p.apiKey, newChatCompletionRequest(req, false))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	responseBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var completion ChatCompletionResponse
+	if err := json.Unmarshal(responseBytes, &completion); err != nil {
+		return "", errors.New("Failed to parse LLM response: " + err.Error() + ", response: " + string(responseBytes))
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("LLM response contains no choices")
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
+
+// StreamGenerateContent streams the completion, which is sent as Server-Sent Events
+func (p *OpenAIProvider) StreamGenerateContent(ctx context.Context, req GenerationRequest, handler StreamHandler) error {
+	resp, err := postJSON(ctx, p.client, p.baseURL+"/chat/completions", p.apiKey, newChatCompletionRequest(req, true))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		// Only data fields carry payloads; comments, event names and blank separators are skipped
+		line = strings.TrimSpace(line)
+		if data, ok := strings.CutPrefix(line, "data:"); ok {
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return handler("", true)
+			}
+
+			var chunk ChatCompletionResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return errors.New("Failed to parse LLM response chunk: " + err.Error())
+			}
+
+			if len(chunk.Choices) > 0 {
+				choice := chunk.Choices[0]
+				done := choice.FinishReason != nil && *choice.FinishReason != ""
+				if err := handler(choice.Delta.Content, done); err != nil {
+					return err
+				}
+				if done {
+					return nil
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			return errLLMStreamIncomplete
+		}
+	}
+}
+
+// modelListResponse is the response of the /models endpoint (OpenAI format)
+type modelListResponse struct {
+	Data []struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+	} `json:"data"`
+}
+
+// ListModels returns the models served by the backend
+func (p *OpenAIProvider) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var list modelListResponse
+	if err := getJSON(ctx, p.client, p.baseURL+"/models", p.apiKey, &list); err != nil {
+		return nil, err
+	}
+
+	models := make([]ModelInfo, 0, len(list.Data))
+	for _, m := range list.Data {
+		model := ModelInfo{Name: m.ID}
+		if m.Created > 0 {
+			created := time.Unix(m.Created, 0)
+			model.ModifiedAt = &created
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// GenerationOptions are per-request overrides of the model and its sampling parameters.
+// Nil fields keep the defaults of the model.
+type GenerationOptions struct {
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumCtx      *int     `json:"num_ctx,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// Limits of the generation options accepted from clients
+const (
+	maxTemperature   = 2.0
+	minNumCtx        = 256
+	maxNumCtx        = 131072
+	maxStopSequences = 4
+	maxStopLength    = 64
+)
+
+// generationOptionParsers is the allowlist of options clients may set
+var generationOptionParsers = map[string]func(opts *GenerationOptions, value interface{}) error{
+	"model": func(opts *GenerationOptions, value interface{}) error {
+		model, ok := value.(string)
+		if !ok || strings.TrimSpace(model) == "" {
+			return fmt.Errorf("must be a non-empty string")
+		}
+		opts.Model = strings.TrimSpace(model)
+		return nil
+	},
+	"temperature": func(opts *GenerationOptions, value interface{}) error {
+		f, err := parseOptionFloat(value, 0, maxTemperature)
+		opts.Temperature = f
+		return err
+	},
+	"top_p": func(opts *GenerationOptions, value interface{}) error {
+		f, err := parseOptionFloat(value, 0, 1)
+		opts.TopP = f
+		return err
+	},
+	"num_ctx": func(opts *GenerationOptions, value interface{}) error {
+		n, err := parseOptionInt(value, minNumCtx, maxNumCtx)
+		opts.NumCtx = n
+		return err
+	},
+	"seed": func(opts *GenerationOptions, value interface{}) error {
+		n, err := parseOptionInt(value, 0, math.MaxInt32)
+		opts.Seed = n
+		return err
+	},
+	"stop": func(opts *GenerationOptions, value interface{}) error {
+		values, ok := value.([]interface{})
+		if !ok || len(values) > maxStopSequences {
+			return fmt.Errorf("must be a list of at most %d strings", maxStopSequences)
+		}
+		for _, v := range values {
+			stop, ok := v.(string)
+			if !ok || stop == "" || len(stop) > maxStopLength {
+				return fmt.Errorf("must contain non-empty strings of at most %d bytes", maxStopLength)
+			}
+			opts.Stop = append(opts.Stop, stop)
+		}
+		return nil
+	},
+}
+
+// ParseGenerationOptions validates options decoded from a JSON object against the allowlist.
+// allowedModels restricts the model option when it is not empty.
+func ParseGenerationOptions(raw map[string]interface{}, allowedModels []string) (*GenerationOptions, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	// Validate in a stable order so that errors are reproducible
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	opts := &GenerationOptions{}
+	for _, key := range keys {
+		parse, ok := generationOptionParsers[key]
+		if !ok {
+			return nil, fmt.Errorf("unsupported option %q", key)
+		}
+		if err := parse(opts, raw[key]); err != nil {
+			return nil, fmt.Errorf("invalid option %q: %v", key, err)
+		}
+	}
+
+	if opts.Model != "" && len(allowedModels) > 0 && !containsModel(allowedModels, opts.Model) {
+		return nil, fmt.Errorf("invalid option \"model\": %q is not one of %s", opts.Model, strings.Join(allowedModels, ", "))
+	}
+	return opts, nil
+}
+
+// ollamaOptions returns the options in the format of Ollama's options object
+func (o *GenerationOptions) ollamaOptions() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+
+	options := make(map[string]interface{})
+	if o.Temperature != nil {
+		options["temperature"] = *o.Temperature
+	}
+	if o.TopP != nil {
+		options["top_p"] = *o.TopP
+	}
+	if o.NumCtx != nil {
+		options["num_ctx"] = *o.NumCtx
+	}
+	if o.Seed != nil {
+		options["seed"] = *o.Seed
+	}
+	if len(o.Stop) > 0 {
+		options["stop"] = o.Stop
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// parseOptionFloat checks that a JSON number lies within [min, max]
+func parseOptionFloat(value interface{}, min, max float64) (*float64, error) {
+	f, ok := value.(float64)
+	if !ok || f < min || f > max {
+		return nil, fmt.Errorf("must be a number between %g and %g", min, max)
+	}
+	return &f, nil
+}
+
+// parseOptionInt checks that a JSON number is an integer within [min, max]
+func parseOptionInt(value interface{}, min, max int) (*int, error) {
+	f, ok := value.(float64)
+	if !ok || f != math.Trunc(f) || f < float64(min) || f > float64(max) {
+		return nil, fmt.Errorf("must be an integer between %d and %d", min, max)
+	}
+	n := int(f)
+	return &n, nil
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// defaultMatchKeywords is how many of the top keywords of a job description a match considers
+const defaultMatchKeywords = 30
+
+// MatchResume rates how well a resume, in markdown or plain text, targets a job description.
+// The score is the share of the keyword weight of the job description that the resume covers.
+func (s *KeywordService) MatchResume(ctx context.Context, jobDescription, resume string, limit int) *models.MatchResult {
+	if limit <= 0 {
+		limit = defaultMatchKeywords
+	}
+	opts := DefaultKeywordOptions()
+	opts.Language = s.DetectLanguage(jobDescription)
+	keywords := s.RankKeywords(ctx, jobDescription, opts)
+	if len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+
+	totalWeight := 0.0
+	for _, k := range keywords {
+		totalWeight += k.Score
+	}
+
+	// Resume text is reduced to terms like the job description so that terms compare as whole
+	// words, regardless of inflection, and skills match under any of their aliases
+	text := " " + strings.Join(termKeys(taxonomy.normalize(resume, commonPhraseSet), opts.Language), " ") + " "
+
+	result := &models.MatchResult{
+		Matched: []models.KeywordMatch{},
+		Missing: []models.KeywordMatch{},
+	}
+	matchedWeight := 0.0
+	for _, k := range keywords {
+		match := models.KeywordMatch{
+			Word:        k.Word,
+			Weight:      roundTo(k.Score/totalWeight, 4),
+			Count:       k.Count,
+			ResumeCount: strings.Count(text, " "+k.Term+" "),
+		}
+		if match.ResumeCount > 0 {
+			matchedWeight += k.Score
+			result.Matched = append(result.Matched, match)
+		} else {
+			result.Missing = append(result.Missing, match)
+		}
+	}
+
+	if len(keywords) > 0 {
+		result.Score = roundTo(100*matchedWeight/totalWeight, 1)
+		result.Coverage = roundTo(float64(len(result.Matched))/float64(len(keywords)), 4)
+	}
+	return result
+}
+
+// roundTo rounds a number to the given number of decimals
+func roundTo(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+)
+
+// ErrModelNotFound is returned when a generation asks for a model the backend does not have
+var ErrModelNotFound = errors.New("model is not installed")
+
+const (
+	// modelCacheTTL is how long the model list of the backend is reused before it is fetched again
+	modelCacheTTL = time.Minute
+	// modelRefreshInterval is how soon a model missing from the cached list triggers another
+	// fetch, so that requests for unknown models cannot flood the backend
+	modelRefreshInterval = 5 * time.Second
+)
+
+// ModelService lists the models of the LLM backend that generations may use
+type ModelService struct {
+	llm       LLMProvider
+	llmConfig *config.LLMConfig
+
+	mu        sync.Mutex
+	models    []ModelInfo
+	fetchedAt time.Time
+}
+
+// NewModelService creates a new ModelService
+func NewModelService(llm LLMProvider, llmConfig *config.LLMConfig) *ModelService {
+	return &ModelService{
+		llm:       llm,
+		llmConfig: llmConfig,
+	}
+}
+
+// DefaultModel returns the model used when a generation does not pick one
+func (s *ModelService) DefaultModel() string {
+	return s.llmConfig.Model
+}
+
+// ListModels returns the installed models that generations may pick, i.e. those allowed by
+// the configuration. The list of the backend is cached briefly.
+func (s *ModelService) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	installed, err := s.installedModels(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := s.llmConfig.AllowedModels
+	models := make([]ModelInfo, 0, len(installed))
+	for _, m := range installed {
+		if len(allowed) == 0 || sameModel(m.Name, s.llmConfig.Model) || containsModel(allowed, m.Name) {
+			models = append(models, m)
+		}
+	}
+	return models, nil
+}
+
+// CheckModel returns ErrModelNotFound when the backend does not have the model. A model
+// missing from the cached list is looked up again, since it may have been installed since.
+func (s *ModelService) CheckModel(ctx context.Context, name string) error {
+	installed, err := s.installedModels(ctx, false)
+	if err != nil {
+		return err
+	}
+	if containsInstalledModel(installed, name) {
+		return nil
+	}
+
+	installed, err = s.installedModels(ctx, true)
+	if err != nil {
+		return err
+	}
+	if containsInstalledModel(installed, name) {
+		return nil
+	}
+	return ErrModelNotFound
+}
+
+// installedModels returns the models of the backend, fetching them when the cache expired.
+// refresh fetches them unless they were fetched within modelRefreshInterval.
+func (s *ModelService) installedModels(ctx context.Context, refresh bool) ([]ModelInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ttl := modelCacheTTL
+	if refresh {
+		ttl = modelRefreshInterval
+	}
+	if s.models != nil && time.Since(s.fetchedAt) < ttl {
+		return s.models, nil
+	}
+
+	models, err := s.llm.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.models = models
+	s.fetchedAt = time.Now()
+	return models, nil
+}
+
+// containsInstalledModel reports whether models contains the model
+func containsInstalledModel(models []ModelInfo, name string) bool {
+	for _, m := range models {
+		if sameModel(m.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsModel reports whether names contains the model
+func containsModel(names []string, model string) bool {
+	for _, name := range names {
+		if sameModel(model, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameModel compares model names, treating a name without a tag as the "latest" tag like Ollama does
+func sameModel(a, b string) bool {
+	return withDefaultTag(a) == withDefaultTag(b)
+}
+
+func withDefaultTag(name string) string {
+	if strings.Contains(name, ":") {
+		return name
+	}
+	return name + ":latest"
+}
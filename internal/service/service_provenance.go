@@ -0,0 +1,240 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// Prefixes of the IDs profile records are labeled with in prompts
+const (
+	workExperienceRefPrefix = "WE-"
+	educationRefPrefix      = "ED-"
+)
+
+// sourceMarkerPrefix starts the source references the model appends to bullet points
+const sourceMarkerPrefix = "[src:"
+
+var sourceMarkerPattern = regexp.MustCompile(`(?i)[ \t]*\[src:\s*([^\]\n]*)\]`)
+
+// recordRef returns the ID a profile record is labeled with in prompts, e.g. "WE-12"
+func recordRef(prefix string, id uint) string {
+	return prefix + strconv.FormatUint(uint64(id), 10)
+}
+
+// sourceIndex resolves record references to the records of a profile
+type sourceIndex map[string]models.SourceRef
+
+// newSourceIndex indexes the work experience and education of a profile by reference
+func newSourceIndex(user *models.User) sourceIndex {
+	index := make(sourceIndex)
+	for _, exp := range user.WorkExperience {
+		index[recordRef(workExperienceRefPrefix, exp.ID)] = models.SourceRef{
+			Kind:  models.SourceWorkExperience,
+			ID:    exp.ID,
+			Label: sourceLabel(exp.Company, exp.StartDate.Year(), exp.EndDate, exp.IsCurrent),
+		}
+	}
+	for _, edu := range user.Education {
+		index[recordRef(educationRefPrefix, edu.ID)] = models.SourceRef{
+			Kind:  models.SourceEducation,
+			ID:    edu.ID,
+			Label: sourceLabel(edu.School, edu.StartDate.Year(), edu.EndDate, edu.IsCurrent),
+		}
+	}
+	return index
+}
+
+// sourceLabel describes a record for display, e.g. "Acme Corp, 2021–2023"
+func sourceLabel(name string, startYear int, end *time.Time, current bool) string {
+	if current || end == nil {
+		return fmt.Sprintf("%s, %d–present", name, startYear)
+	}
+	if end.Year() == startYear {
+		return fmt.Sprintf("%s, %d", name, startYear)
+	}
+	return fmt.Sprintf("%s, %d–%d", name, startYear, end.Year())
+}
+
+// resolve splits a list of references such as "WE-12, ED-3" into the records of the
+// profile and the references that match none
+func (idx sourceIndex) resolve(refs string) ([]models.SourceRef, []string) {
+	sources := []models.SourceRef{}
+	var invalid []string
+	seen := make(map[string]bool)
+	for _, ref := range strings.FieldsFunc(refs, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+		ref = strings.ToUpper(strings.Trim(ref, "[]"))
+		if ref == "" || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		if source, ok := idx[ref]; ok {
+			sources = append(sources, source)
+		} else {
+			invalid = append(invalid, ref)
+		}
+	}
+	return sources, invalid
+}
+
+// extractProvenance removes the source markers from generated markdown and returns the
+// references of each bullet point, validated against the profile
+func extractProvenance(content string, user *models.User) (string, models.Provenance) {
+	index := newSourceIndex(user)
+	provenance := models.Provenance{}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		matches := sourceMarkerPattern.FindAllStringSubmatch(line, -1)
+		if matches == nil {
+			continue
+		}
+		line = strings.TrimRight(sourceMarkerPattern.ReplaceAllString(line, ""), " \t")
+		lines[i] = line
+		if !bulletPattern.MatchString(line) {
+			continue
+		}
+
+		entry := models.BulletProvenance{Text: bulletText(line), Sources: []models.SourceRef{}}
+		for _, m := range matches {
+			sources, invalid := index.resolve(m[1])
+			entry.Sources = append(entry.Sources, sources...)
+			entry.Invalid = append(entry.Invalid, invalid...)
+		}
+		provenance = append(provenance, entry)
+	}
+	return strings.Join(lines, "\n"), provenance
+}
+
+// locateProvenance sets the line of each bullet point in the final content. Bullet points
+// that are no longer in the content, e.g. because verification stripped them, are dropped.
+func locateProvenance(content string, provenance models.Provenance) models.Provenance {
+	lines := strings.Split(content, "\n")
+	located := models.Provenance{}
+	next := 0
+	for _, entry := range provenance {
+		for i := next; i < len(lines); i++ {
+			if bulletPattern.MatchString(lines[i]) && bulletText(lines[i]) == entry.Text {
+				entry.Location = fmt.Sprintf("line %d", i+1)
+				located = append(located, entry)
+				next = i + 1
+				break
+			}
+		}
+	}
+	return located
+}
+
+// bulletText returns the text of a bullet point without its marker
+func bulletText(line string) string {
+	return strings.TrimSpace(bulletPattern.ReplaceAllString(line, ""))
+}
+
+// structuredProvenance validates the sources of the experience entries of a structured
+// resume. Invalid sources are cleared; each bullet point inherits the source of its entry.
+func structuredProvenance(content *models.ResumeContent, user *models.User) models.Provenance {
+	index := newSourceIndex(user)
+	provenance := models.Provenance{}
+	for i := range content.Experience {
+		exp := &content.Experience[i]
+		sources, invalid := index.resolve(exp.Source)
+		exp.Source = strings.Join(validRefs(exp.Source, invalid), ", ")
+
+		for j, bullet := range exp.Description {
+			provenance = append(provenance, models.BulletProvenance{
+				Text:     bullet,
+				Location: fmt.Sprintf("experience[%d].description[%d]", i, j),
+				Sources:  sources,
+				Invalid:  invalid,
+			})
+		}
+	}
+	return provenance
+}
+
+// validRefs returns the references of a list that are not invalid
+func validRefs(refs string, invalid []string) []string {
+	var valid []string
+	for _, ref := range strings.FieldsFunc(refs, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+		ref = strings.ToUpper(strings.Trim(ref, "[]"))
+		if ref != "" && !containsString(invalid, ref) && !containsString(valid, ref) {
+			valid = append(valid, ref)
+		}
+	}
+	return valid
+}
+
+// sourceMarkerFilter removes source markers from streamed output. Text that may start a
+// marker is held back until the marker is complete or turns out to be something else.
+type sourceMarkerFilter struct {
+	pending string
+}
+
+// Write returns the part of the output so far that is safe to pass on
+func (f *sourceMarkerFilter) Write(chunk string) string {
+	text := f.pending + chunk
+	f.pending = ""
+
+	var out strings.Builder
+	for {
+		start := strings.IndexByte(text, '[')
+		if start < 0 {
+			out.WriteString(text)
+			return f.holdTrailingSpace(&out)
+		}
+		out.WriteString(text[:start])
+		text = text[start:]
+
+		// Not a marker, or not yet known
+		prefix := strings.ToLower(text[:min(len(text), len(sourceMarkerPrefix))])
+		if !strings.HasPrefix(sourceMarkerPrefix, prefix) {
+			out.WriteByte('[')
+			text = text[1:]
+			continue
+		}
+		if len(prefix) < len(sourceMarkerPrefix) {
+			f.pending = text
+			return f.holdTrailingSpace(&out)
+		}
+
+		end := strings.IndexAny(text, "]\n")
+		if end < 0 {
+			f.pending = text
+			return f.holdTrailingSpace(&out)
+		}
+		if text[end] == '\n' {
+			// An unterminated marker is passed on as it is
+			out.WriteString(text[:end])
+			text = text[end:]
+			continue
+		}
+		text = text[end+1:]
+		f.dropTrailingSpace(&out)
+	}
+}
+
+// Flush returns the output held back at the end of the stream
+func (f *sourceMarkerFilter) Flush() string {
+	pending := f.pending
+	f.pending = ""
+	return pending
+}
+
+// holdTrailingSpace keeps trailing blanks back, since they belong to a marker that may follow
+func (f *sourceMarkerFilter) holdTrailingSpace(out *strings.Builder) string {
+	text := out.String()
+	trimmed := strings.TrimRight(text, " \t")
+	f.pending = text[len(trimmed):] + f.pending
+	return trimmed
+}
+
+// dropTrailingSpace removes the blanks before a removed marker
+func (f *sourceMarkerFilter) dropTrailingSpace(out *strings.Builder) {
+	text := strings.TrimRight(out.String(), " \t")
+	out.Reset()
+	out.WriteString(text)
+}
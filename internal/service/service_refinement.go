@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repository"
+)
+
+var (
+	// ErrResumeNotMarkdown is returned when refining a resume that is not stored as markdown
+	ErrResumeNotMarkdown = errors.New("only markdown resumes can be refined")
+	// ErrInvalidTurn is returned when rolling back to a turn the session does not have
+	ErrInvalidTurn = errors.New("session has no such turn")
+)
+
+// RefinementService revises saved resumes in multi-turn conversations with the LLM
+type RefinementService struct {
+	refinementRepo *repository.RefinementRepository
+	resumeRepo     *repository.ResumeRepository
+	resumeService  *ResumeService
+	llm            LLMProvider
+}
+
+func NewRefinementService(refinementRepo *repository.RefinementRepository, resumeRepo *repository.ResumeRepository, resumeService *ResumeService, llm LLMProvider) *RefinementService {
+	return &RefinementService{
+		refinementRepo: refinementRepo,
+		resumeRepo:     resumeRepo,
+		resumeService:  resumeService,
+		llm:            llm,
+	}
+}
+
+// RefineParams holds the inputs of a refinement turn
+type RefineParams struct {
+	UserID      uint
+	SessionID   uint
+	Instruction string // e.g. "Emphasize Kubernetes more"
+	Options     *GenerationOptions
+
+	OnQueuePosition func(position int) // Called with the queue position while waiting for the model
+}
+
+// CreateSession starts a refinement session on the current content of a resume version
+func (s *RefinementService) CreateSession(ctx context.Context, userID, resumeID uint) (*models.RefinementSession, error) {
+	resume, err := s.resumeRepo.GetResumeByID(ctx, userID, resumeID)
+	if err != nil {
+		return nil, err
+	}
+	if resume.Format != models.ResumeFormatMarkdown {
+		return nil, ErrResumeNotMarkdown
+	}
+
+	session := &models.RefinementSession{
+		UserID:      userID,
+		ResumeID:    resumeID,
+		BaseContent: resume.Content,
+		Turns:       []models.RefinementTurn{},
+	}
+	if err := s.refinementRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetSession retrieves a refinement session with its turns
+func (s *RefinementService) GetSession(ctx context.Context, userID, sessionID uint) (*models.RefinementSession, error) {
+	return s.refinementRepo.GetSession(ctx, userID, sessionID)
+}
+
+// ListSessions retrieves the refinement sessions of a resume version
+func (s *RefinementService) ListSessions(ctx context.Context, userID, resumeID uint) ([]models.RefinementSession, error) {
+	return s.refinementRepo.GetSessionsByResumeID(ctx, userID, resumeID)
+}
+
+// DeleteSession deletes a refinement session
+func (s *RefinementService) DeleteSession(ctx context.Context, userID, sessionID uint) error {
+	return s.refinementRepo.DeleteSession(ctx, userID, sessionID)
+}
+
+// CheckSession returns repository.ErrResumeChanged when the resume of a session no longer
+// has the content of the session's latest turn, e.g. because it was edited since
+func (s *RefinementService) CheckSession(ctx context.Context, session *models.RefinementSession) error {
+	_, err := s.sessionResume(ctx, session)
+	return err
+}
+
+// Refine asks the LLM to revise the current resume of a session according to an instruction.
+// Recent turns are sent as conversation history. The revised resume is streamed to handler
+// and saved as a new turn, which also becomes the content of the resume version. It returns
+// repository.ErrResumeChanged when the resume was changed outside the session, by an edit or
+// a concurrent turn, rather than overwrite that change.
+func (s *RefinementService) Refine(ctx context.Context, params RefineParams, handler ResumeStreamHandler) (*models.RefinementTurn, error) {
+	if s.llm == nil {
+		return nil, fmt.Errorf("LLM service is not available")
+	}
+
+	session, err := s.refinementRepo.GetSession(ctx, params.UserID, params.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	resume, err := s.sessionResume(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	req := GenerationRequest{
+		Model:           s.resumeService.GenerationModel(params.Options),
+		System:          refinementSystemPrompt,
+		Messages:        refinementHistory(session),
+		Prompt:          strings.TrimSpace(params.Instruction),
+		Options:         params.Options,
+		UserID:          params.UserID,
+		OnQueuePosition: params.OnQueuePosition,
+	}
+
+	var content strings.Builder
+	err = s.llm.StreamGenerateContent(ctx, req, func(chunk string, done bool) error {
+		content.WriteString(chunk)
+		return handler(chunk, done)
+	})
+	if errors.Is(err, ErrLLMQueueFull) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream resume revision: %v", err)
+	}
+
+	turn := &models.RefinementTurn{
+		Instruction: req.Prompt,
+		Content:     strings.TrimSpace(content.String()),
+	}
+	revision, err := s.revision(ctx, session, resume, turn.Content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refinementRepo.AddTurn(ctx, session, turn, revision); err != nil {
+		if errors.Is(err, repository.ErrResumeChanged) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to save resume revision: %v", err)
+	}
+	return turn, nil
+}
+
+// Rollback discards the turns after the given turn and restores its resume content.
+// Turn 0 restores the content the session started with.
+func (s *RefinementService) Rollback(ctx context.Context, userID, sessionID uint, number int) (*models.RefinementSession, error) {
+	session, err := s.refinementRepo.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if number < 0 || number > session.CurrentTurn() {
+		return nil, ErrInvalidTurn
+	}
+	resume, err := s.sessionResume(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := s.revision(ctx, session, resume, session.ContentAt(number))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refinementRepo.RollbackSession(ctx, session, number, revision); err != nil {
+		return nil, err
+	}
+
+	turns := session.Turns[:0]
+	for _, turn := range session.Turns {
+		if turn.Number <= number {
+			turns = append(turns, turn)
+		}
+	}
+	session.Turns = turns
+	return session, nil
+}
+
+// sessionResume retrieves the resume of a session, provided it still has the content of the
+// session's latest turn
+func (s *RefinementService) sessionResume(ctx context.Context, session *models.RefinementSession) (*models.Resume, error) {
+	resume, err := s.resumeRepo.GetResumeByID(ctx, session.UserID, session.ResumeID)
+	if err != nil {
+		return nil, err
+	}
+	if resume.Content != session.CurrentContent() {
+		return nil, repository.ErrResumeChanged
+	}
+	return resume, nil
+}
+
+// revision returns what a session writes to its resume: the content, its findings against the
+// profile, and the provenance of the bullet points the content still has
+func (s *RefinementService) revision(ctx context.Context, session *models.RefinementSession, resume *models.Resume, content string) (*repository.ResumeRevision, error) {
+	revision := &repository.ResumeRevision{
+		Content:    content,
+		Provenance: locateProvenance(content, resume.Provenance),
+	}
+	if s.resumeService.llmConfig.VerifyMode == config.VerifyModeOff {
+		return revision, nil
+	}
+
+	user, err := s.resumeService.userService.GetUserWithDetails(ctx, session.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user data: %v", err)
+	}
+	// Only flagged: the content is what the user asked for
+	_, revision.Findings = verifyMarkdown(content, user, config.VerifyModeFlag)
+	return revision, nil
+}
+
+// refinementSystemPrompt holds the instructions for revising a resume, sent as the system message
+const refinementSystemPrompt = `You are a professional resume writer helping a candidate refine their resume. The first user message contains the current resume in markdown format. Each following user message asks for a change.
+
+Apply the requested change and respond with the complete revised resume in markdown format and nothing else. Keep everything that the change does not concern as it is. Do not make up or add any information that is not in the resume or in the user's messages.`
+
+// maxRefinementHistoryTurns is how many of the latest turns are sent to the LLM in full. Each
+// turn holds a complete resume, so older turns are only listed by their instructions.
+const maxRefinementHistoryTurns = 3
+
+// refinementHistory returns the conversation so far: the resume before the latest turns, then
+// each of these turns' instructions with the revision it produced. The instructions of older
+// turns are listed with the resume so that the LLM does not undo them.
+func refinementHistory(session *models.RefinementSession) []ChatMessage {
+	turns := session.Turns
+	first := "Current resume:\n\n" + session.BaseContent
+	if len(turns) > maxRefinementHistoryTurns {
+		older := turns[:len(turns)-maxRefinementHistoryTurns]
+		applied := make([]string, 0, len(older))
+		for _, turn := range older {
+			applied = append(applied, "- "+turn.Instruction)
+		}
+		first = "Changes already made to the resume:\n" + strings.Join(applied, "\n") +
+			"\n\nCurrent resume:\n\n" + older[len(older)-1].Content
+		turns = turns[len(older):]
+	}
+
+	messages := []ChatMessage{{Role: RoleUser, Content: first}}
+	for _, turn := range turns {
+		messages = append(messages,
+			ChatMessage{Role: RoleUser, Content: turn.Instruction},
+			ChatMessage{Role: RoleAssistant, Content: turn.Content},
+		)
+	}
+	return messages
+}
@@ -2,118 +2,267 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
 	"github.com/nikolai/ai-resume-builder/backend/internal/interfaces"
 	"github.com/nikolai/ai-resume-builder/backend/internal/models"
 )
 
 type ResumeService struct {
 	db             interfaces.DB
+	resumeRepo     interfaces.ResumeRepository
 	keywordService *KeywordService
-	llmService     *LLMService
+	llm            LLMProvider
 	userService    *UserService
+	llmConfig      *config.LLMConfig
 }
 
-func NewResumeService(db interfaces.DB, keywordService *KeywordService, llmService *LLMService, userService *UserService) *ResumeService {
+func NewResumeService(db interfaces.DB, resumeRepo interfaces.ResumeRepository, keywordService *KeywordService, llm LLMProvider, userService *UserService, llmConfig *config.LLMConfig) *ResumeService {
 	return &ResumeService{
 		db:             db,
+		resumeRepo:     resumeRepo,
 		keywordService: keywordService,
-		llmService:     llmService,
+		llm:            llm,
 		userService:    userService,
+		llmConfig:      llmConfig,
 	}
 }
 
+// ParseGenerationOptions validates the generation options of a request against the allowlist
+// and the models allowed by the configuration
+func (s *ResumeService) ParseGenerationOptions(raw map[string]interface{}) (*GenerationOptions, error) {
+	return ParseGenerationOptions(raw, s.llmConfig.AllowedModels)
+}
+
+// GenerationModel returns the model a generation with the given options runs on
+func (s *ResumeService) GenerationModel(opts *GenerationOptions) string {
+	if opts != nil && opts.Model != "" {
+		return opts.Model
+	}
+	return s.llmConfig.Model
+}
+
+// generationRequest builds the LLM request of a generation, applying its options
+func (s *ResumeService) generationRequest(params GenerateResumeParams, system, prompt string) GenerationRequest {
+	return GenerationRequest{
+		Model:           s.GenerationModel(params.Options),
+		System:          system,
+		Prompt:          prompt,
+		Options:         params.Options,
+		UserID:          params.UserID,
+		OnQueuePosition: params.OnQueuePosition,
+		Background:      params.Background,
+	}
+}
+
+// verifyMode returns how a generation is checked against the user's profile
+func (s *ResumeService) verifyMode(params GenerateResumeParams) string {
+	if params.VerifyMode != "" {
+		return params.VerifyMode
+	}
+	return s.llmConfig.VerifyMode
+}
+
 // ResumeStreamHandler is a function that handles streaming resume chunks
 type ResumeStreamHandler func(chunk string, done bool) error
 
-// GenerateResume generates a resume based on the job description and streams the results
-func (s *ResumeService) GenerateResume(ctx context.Context, userID uint, jobDescription string, handler ResumeStreamHandler) error {
-	// Fetch user with related data
-	user, err := s.userService.GetUserWithDetails(ctx, userID)
-	if err != nil {
-		return fmt.Errorf("failed to fetch user data: %v", err)
-	}
+// GenerateResumeParams holds the inputs of a resume generation
+type GenerateResumeParams struct {
+	UserID         uint   `json:"userId"`
+	JobDescription string `json:"jobDescription"`
+	Name           string `json:"name,omitempty"` // Name of the saved resume version, derived from JobTitle and Company when empty
+	JobTitle       string `json:"jobTitle,omitempty"`
+	Company        string `json:"company,omitempty"`
 
-	// Extract keywords from job description
-	keywords := s.keywordService.ExtractAndRankKeywords(jobDescription)
+	Options    *GenerationOptions `json:"options,omitempty"`    // Overrides of the model and its sampling parameters
+	VerifyMode string             `json:"verifyMode,omitempty"` // Overrides the configured check against the profile
 
-	// Prepare keywords for the LLM
-	keywordStrings := make([]string, 0, len(keywords))
+	OnQueuePosition func(position int) `json:"-"` // Called with the queue position while waiting for the model
+	Background      bool               `json:"-"` // Runs in a background job, see GenerationRequest.Background
+}
 
-	// Use up to 10 keywords, but handle cases where fewer are available
-	maxKeywords := 10
-	if len(keywords) < maxKeywords {
-		maxKeywords = len(keywords)
+// GenerateResume generates a resume based on the job description, streams the results
+// and saves the complete output as a new resume version
+func (s *ResumeService) GenerateResume(ctx context.Context, params GenerateResumeParams, handler ResumeStreamHandler) (*models.Resume, error) {
+	// Fetch user with related data
+	user, err := s.userService.GetUserWithDetails(ctx, params.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user data: %v", err)
 	}
 
-	// Safely get the top keywords
-	for _, k := range keywords[:maxKeywords] {
-		keywordStrings = append(keywordStrings, k.Word)
-	}
+	// Extract the top keywords from the job description
+	keywordStrings := s.topKeywords(ctx, params.JobDescription, 10)
 
 	// If LLM service is available, use it to generate the resume with streaming
-	if s.llmService != nil {
+	if s.llm != nil {
 		// Prepare a prompt for the LLM
-		prompt := s.buildPrompt(keywordStrings, jobDescription, user)
+		system, prompt := s.buildPrompt(keywordStrings, params.JobDescription, user)
 
-		// Stream the LLM responses
-		err := s.llmService.StreamGenerateContent(ctx, "cusmodel1.2", prompt, func(chunk string, done bool) error {
+		// Stream the LLM responses, keeping the full output so it can be saved. Source markers
+		// are kept out of the stream and turned into provenance afterwards.
+		var content strings.Builder
+		var markers sourceMarkerFilter
+		req := s.generationRequest(params, system, prompt)
+		err := s.llm.StreamGenerateContent(ctx, req, func(chunk string, done bool) error {
+			content.WriteString(chunk)
+			chunk = markers.Write(chunk)
+			if done {
+				chunk += markers.Flush()
+			}
+			if chunk == "" && !done {
+				return nil
+			}
 			return handler(chunk, done)
 		})
 
+		if errors.Is(err, ErrLLMQueueFull) {
+			return nil, err
+		}
 		if err != nil {
-			return fmt.Errorf("failed to stream resume generation: %v", err)
+			return nil, fmt.Errorf("failed to stream resume generation: %v", err)
+		}
+
+		// The output was streamed as generated; in strip mode the saved version is the cleaned one
+		output, provenance := extractProvenance(content.String(), user)
+		var findings models.VerificationFindings
+		if mode := s.verifyMode(params); mode != config.VerifyModeOff {
+			output, findings = verifyMarkdown(output, user, mode)
+		}
+
+		resume := newGeneratedResume(params, output, models.ResumeFormatMarkdown)
+		resume.Findings = findings
+		resume.Provenance = locateProvenance(output, provenance)
+		if err := s.resumeRepo.CreateResume(ctx, resume); err != nil {
+			return nil, fmt.Errorf("failed to save generated resume: %v", err)
 		}
 
-		return nil
+		return resume, nil
 	}
 
-	return fmt.Errorf("LLM service is not available")
+	return nil, fmt.Errorf("LLM service is not available")
 }
 
-// buildPrompt creates a prompt for the LLM based on the extracted keywords and user data
-func (s *ResumeService) buildPrompt(keywordStrings []string, jobDescription string, user *models.User) string {
-	// Format personal information
-	personalInfo := fmt.Sprintf("Name: %s\nEmail: %s\nPhone: %s\nLocation: %s\nTitle: %s\nSummary: %s\n\n",
-		user.FullName,
-		user.Email,
-		user.Phone,
-		user.Location,
-		user.Title,
-		user.Summary,
-	)
+// topKeywords returns up to limit of the highest ranked keywords of a job description.
+// The job description is added to the keyword corpus, which ranks later postings.
+func (s *ResumeService) topKeywords(ctx context.Context, jobDescription string, limit int) []string {
+	keywords := s.keywordService.ExtractAndRankKeywords(ctx, jobDescription)
+	if _, err := s.keywordService.IngestJobDescription(ctx, jobDescription); err != nil {
+		log.Printf("Failed to add job description to keyword corpus: %v", err)
+	}
+	if len(keywords) < limit {
+		limit = len(keywords)
+	}
 
-	// Format work experience
-	experience := ""
-	for _, exp := range user.WorkExperience {
-		experience += fmt.Sprintf("- %s at %s (%s - %s)\n", exp.Title, exp.Company, exp.StartDate.Format("Jan 2006"), getEndDate(exp))
-		experience += fmt.Sprintf("  Location: %s\n", exp.Location)
-		experience += fmt.Sprintf("  Description: %s\n\n", exp.Description)
+	keywordStrings := make([]string, 0, limit)
+	for _, k := range keywords[:limit] {
+		keywordStrings = append(keywordStrings, k.Word)
 	}
+	return keywordStrings
+}
 
-	// Format education
-	education := ""
-	for _, edu := range user.Education {
-		education += fmt.Sprintf("- %s in %s from %s (%s - %s)\n", edu.Degree, edu.Field, edu.School, edu.StartDate.Format("Jan 2006"), getEducationEndDate(edu))
-		education += fmt.Sprintf("  Location: %s\n", edu.Location)
-		education += fmt.Sprintf("  Description: %s\n\n", edu.Description)
+// newGeneratedResume builds the resume version saved for a generation
+func newGeneratedResume(params GenerateResumeParams, content, format string) *models.Resume {
+	resume := &models.Resume{
+		UserID:   params.UserID,
+		Name:     params.Name,
+		JobTitle: params.JobTitle,
+		Company:  params.Company,
+		Content:  content,
+		Format:   format,
 	}
+	if resume.Name == "" {
+		resume.Name = defaultResumeName(params.JobTitle, params.Company)
+	}
+	return resume
+}
 
-	// Format skills
-	skills := ""
-	for _, skill := range keywordStrings {
-		skills += fmt.Sprintf("- %s\n", skill)
+var (
+	// ErrResumeUserRequired is returned when saving a resume without a user
+	ErrResumeUserRequired = errors.New("user ID is required")
+	// ErrResumeIDRequired is returned when updating a resume without its ID
+	ErrResumeIDRequired = errors.New("resume ID is required")
+	// ErrResumeNameRequired is returned when saving a resume without a name
+	ErrResumeNameRequired = errors.New("resume name is required")
+	// ErrInvalidResumeContent is returned when saving a JSON resume whose content is not a valid ResumeContent document
+	ErrInvalidResumeContent = errors.New("content is not a valid resume document")
+)
+
+// CreateResume saves a new resume version for a user
+func (s *ResumeService) CreateResume(ctx context.Context, resume *models.Resume) error {
+	if resume.UserID == 0 {
+		return ErrResumeUserRequired
+	}
+	if strings.TrimSpace(resume.Name) == "" {
+		return ErrResumeNameRequired
+	}
+	if err := normalizeResumeContent(resume); err != nil {
+		return err
 	}
+	return s.resumeRepo.CreateResume(ctx, resume)
+}
 
-	return fmt.Sprintf(
-		`You are a professional resume writer. Your task is to create an ATS-optimized resume in markdown format using ONLY the information provided below. Do not make up or add any information that is not explicitly provided.
+// ListResumes retrieves all resume versions of a user
+func (s *ResumeService) ListResumes(ctx context.Context, userID uint) ([]models.Resume, error) {
+	return s.resumeRepo.GetResumesByUserID(ctx, userID)
+}
 
-IMPORTANT: Use the exact name, contact details, and information provided in the Personal Information section. Do not modify or change any of these details.
+// GetResume retrieves a single resume version of a user
+func (s *ResumeService) GetResume(ctx context.Context, userID, resumeID uint) (*models.Resume, error) {
+	return s.resumeRepo.GetResumeByID(ctx, userID, resumeID)
+}
 
-Use markdown syntax for formatting (e.g., # for headings, * for emphasis, etc.).
+// UpdateResume updates a resume version of a user
+func (s *ResumeService) UpdateResume(ctx context.Context, resume *models.Resume) error {
+	if resume.ID == 0 {
+		return ErrResumeIDRequired
+	}
+	if strings.TrimSpace(resume.Name) == "" {
+		return ErrResumeNameRequired
+	}
+	if err := normalizeResumeContent(resume); err != nil {
+		return err
+	}
+	return s.resumeRepo.UpdateResume(ctx, resume)
+}
+
+// DeleteResume deletes a resume version of a user
+func (s *ResumeService) DeleteResume(ctx context.Context, userID, resumeID uint) error {
+	if err := s.resumeRepo.DeleteResume(ctx, userID, resumeID); err != nil {
+		return err
+	}
+	s.removeResumeIndex(ctx, resumeID)
+	return nil
+}
+
+// defaultResumeName builds a resume name from its target, e.g. "Software Engineer - Google"
+func defaultResumeName(jobTitle, company string) string {
+	jobTitle = strings.TrimSpace(jobTitle)
+	company = strings.TrimSpace(company)
+	switch {
+	case jobTitle != "" && company != "":
+		return jobTitle + " - " + company
+	case jobTitle != "":
+		return jobTitle
+	case company != "":
+		return company
+	}
+	return "Resume " + time.Now().Format("2006-01-02 15:04")
+}
+
+// buildPrompt creates the system instructions and the user message for the LLM based on the extracted keywords and user data
+func (s *ResumeService) buildPrompt(keywordStrings []string, jobDescription string, user *models.User) (string, string) {
+	personalInfo, experience, education := formatProfile(user)
+
+	// Format skills
+	skills := formatSkillList(keywordStrings)
 
-Personal Information:
+	return resumeSystemPrompt, fmt.Sprintf(
+		`Personal Information:
 %s
 
 Job Description:
@@ -133,6 +282,55 @@ Generate a professional resume that highlights the candidate's experience and sk
 	)
 }
 
+// resumeSystemPrompt holds the instructions for markdown resume generation, sent as the system message
+const resumeSystemPrompt = `You are a professional resume writer. Your task is to create an ATS-optimized resume in markdown format using ONLY the information provided by the user. Do not make up or add any information that is not explicitly provided.
+
+IMPORTANT: Use the exact name, contact details, and information provided in the Personal Information section. Do not modify or change any of these details.
+
+Use markdown syntax for formatting (e.g., # for headings, * for emphasis, etc.).
+
+Each Experience and Education record is labeled with an ID such as [WE-12] or [ED-3]. End every bullet point with the IDs of the records it is based on, e.g. "- Led the migration to Kubernetes [src: WE-12]". Do not put IDs anywhere else.`
+
+// formatProfile renders the user's personal information, work experience and education for a prompt
+func formatProfile(user *models.User) (string, string, string) {
+	// Format personal information
+	personalInfo := fmt.Sprintf("Name: %s\nEmail: %s\nPhone: %s\nLocation: %s\nTitle: %s\nSummary: %s\n\n",
+		user.FullName,
+		user.Email,
+		user.Phone,
+		user.Location,
+		user.Title,
+		user.Summary,
+	)
+
+	// Format work experience, labeling each record with the ID generated content refers to
+	experience := ""
+	for _, exp := range user.WorkExperience {
+		experience += fmt.Sprintf("- [%s] %s at %s (%s - %s)\n", recordRef(workExperienceRefPrefix, exp.ID), exp.Title, exp.Company, exp.StartDate.Format("Jan 2006"), getEndDate(exp))
+		experience += fmt.Sprintf("  Location: %s\n", exp.Location)
+		experience += fmt.Sprintf("  Description: %s\n\n", exp.Description)
+	}
+
+	// Format education
+	education := ""
+	for _, edu := range user.Education {
+		education += fmt.Sprintf("- [%s] %s in %s from %s (%s - %s)\n", recordRef(educationRefPrefix, edu.ID), edu.Degree, edu.Field, edu.School, edu.StartDate.Format("Jan 2006"), getEducationEndDate(edu))
+		education += fmt.Sprintf("  Location: %s\n", edu.Location)
+		education += fmt.Sprintf("  Description: %s\n\n", edu.Description)
+	}
+
+	return personalInfo, experience, education
+}
+
+// formatSkillList renders keywords as a bullet list
+func formatSkillList(keywordStrings []string) string {
+	skills := ""
+	for _, skill := range keywordStrings {
+		skills += fmt.Sprintf("- %s\n", skill)
+	}
+	return skills
+}
+
 func getEndDate(exp models.WorkExperience) string {
 	if exp.IsCurrent {
 		return "Present"
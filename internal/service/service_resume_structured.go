@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// ErrResumeNotStructured is returned when typed content is requested for a markdown resume
+var ErrResumeNotStructured = errors.New("resume is not stored as structured content")
+
+// maxStructuredRepairAttempts is how many times the model is asked to fix invalid JSON output
+const maxStructuredRepairAttempts = 1
+
+// GenerateStructuredResume generates a resume as a typed ResumeContent, asking the LLM for JSON.
+// The output is repaired and validated before it is saved as a new resume version.
+func (s *ResumeService) GenerateStructuredResume(ctx context.Context, params GenerateResumeParams) (*models.Resume, *models.ResumeContent, error) {
+	if s.llm == nil {
+		return nil, nil, fmt.Errorf("LLM service is not available")
+	}
+
+	user, err := s.userService.GetUserWithDetails(ctx, params.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch user data: %v", err)
+	}
+
+	keywordStrings := s.topKeywords(ctx, params.JobDescription, 10)
+	system, prompt := s.buildStructuredPrompt(keywordStrings, params.JobDescription, user)
+
+	req := s.generationRequest(params, system, prompt)
+	req.JSON = true
+	output, err := s.llm.GenerateContent(ctx, req)
+	if errors.Is(err, ErrLLMQueueFull) {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate structured resume: %v", err)
+	}
+
+	content, err := parseResumeContent(output)
+	for attempt := 0; err != nil && attempt < maxStructuredRepairAttempts; attempt++ {
+		// Give the model its own output and the problems found so it can correct them
+		repairRequest := req
+		repairRequest.Prompt = buildRepairPrompt(output, err)
+		output, err = s.llm.GenerateContent(ctx, repairRequest)
+		if errors.Is(err, ErrLLMQueueFull) {
+			return nil, nil, err
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to repair structured resume: %v", err)
+		}
+		content, err = parseResumeContent(output)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("LLM returned an invalid resume: %v", err)
+	}
+
+	var findings models.VerificationFindings
+	if mode := s.verifyMode(params); mode != config.VerifyModeOff {
+		findings = verifyStructured(content, user, mode)
+	}
+
+	// Contact details always come from the profile, never from the model
+	applyPersonalInfo(content, user)
+	provenance := structuredProvenance(content, user)
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resume := newGeneratedResume(params, string(data), models.ResumeFormatJSON)
+	resume.Findings = findings
+	resume.Provenance = provenance
+	if err := s.resumeRepo.CreateResume(ctx, resume); err != nil {
+		return nil, nil, fmt.Errorf("failed to save generated resume: %v", err)
+	}
+
+	return resume, content, nil
+}
+
+// GetResumeContent retrieves the typed content of a resume version generated in JSON mode
+func (s *ResumeService) GetResumeContent(ctx context.Context, userID, resumeID uint) (*models.ResumeContent, error) {
+	resume, err := s.resumeRepo.GetResumeByID(ctx, userID, resumeID)
+	if err != nil {
+		return nil, err
+	}
+	if resume.Format != models.ResumeFormatJSON {
+		return nil, ErrResumeNotStructured
+	}
+
+	var content models.ResumeContent
+	if err := json.Unmarshal([]byte(resume.Content), &content); err != nil {
+		return nil, fmt.Errorf("failed to decode resume content: %v", err)
+	}
+	return &content, nil
+}
+
+// normalizeResumeContent checks that the content of a JSON resume decodes as a ResumeContent
+// document and stores it in canonical form, so that it can be decoded when read back. Unlike
+// model output, content saved by users may leave fields empty, such as a summary still to be
+// written.
+func normalizeResumeContent(resume *models.Resume) error {
+	if resume.Format != models.ResumeFormatJSON {
+		return nil
+	}
+	var content *models.ResumeContent
+	if err := json.Unmarshal([]byte(resume.Content), &content); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResumeContent, err)
+	}
+	if content == nil {
+		return fmt.Errorf("%w: content is null", ErrInvalidResumeContent)
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to encode resume content: %v", err)
+	}
+	resume.Content = string(data)
+	return nil
+}
+
+// buildStructuredPrompt creates the system instructions and the user message asking the LLM for a resume as a ResumeContent JSON document
+func (s *ResumeService) buildStructuredPrompt(keywordStrings []string, jobDescription string, user *models.User) (string, string) {
+	personalInfo, experience, education := formatProfile(user)
+	skills := formatSkillList(keywordStrings)
+
+	system := fmt.Sprintf(
+		`You are a professional resume writer. Your task is to create an ATS-optimized resume using ONLY the information provided by the user. Do not make up or add any information that is not explicitly provided.
+
+IMPORTANT: Use the exact name, contact details, companies, titles, schools, degrees and dates provided. Do not modify or change any of these details.
+
+Respond with a single JSON object and nothing else. The object must have exactly this structure:
+%s
+
+Dates use the format "YYYY-MM". Leave "endDate" empty and set "current" to true for ongoing positions.
+Write 2-5 concise, achievement-oriented bullet points in "description" for each experience.
+Each Experience record is labeled with an ID such as [WE-12]; set "source" of an experience to the ID of the record it is based on.`,
+		resumeContentSchema,
+	)
+
+	return system, fmt.Sprintf(
+		`Personal Information:
+%s
+
+Job Description:
+%s
+
+Experience:
+%s
+
+Skills:
+%s
+
+Education:
+%s`,
+		personalInfo, jobDescription, experience, skills, education,
+	)
+}
+
+// resumeContentSchema is an example document describing the JSON expected from the model
+const resumeContentSchema = `{
+  "personalInfo": {"name": "", "email": "", "phone": "", "address": "", "linkedin": "", "github": ""},
+  "summary": "",
+  "experience": [
+    {"company": "", "title": "", "startDate": "YYYY-MM", "endDate": "YYYY-MM", "current": false, "location": "", "description": [""], "source": "WE-12"}
+  ],
+  "education": [
+    {"school": "", "degree": "", "field": "", "location": "", "startDate": "YYYY-MM", "endDate": "YYYY-MM", "current": false, "description": ""}
+  ],
+  "skills": [""],
+  "projects": [
+    {"name": "", "description": "", "technologies": [""], "url": ""}
+  ]
+}`
+
+// buildRepairPrompt asks the LLM to fix a previous output that failed validation
+func buildRepairPrompt(output string, problem error) string {
+	return fmt.Sprintf(
+		`The following resume JSON is invalid: %v
+
+Fix it and respond with a single corrected JSON object with this structure and nothing else:
+%s
+
+Invalid JSON:
+%s`,
+		problem, resumeContentSchema, output,
+	)
+}
+
+// rawResumeContent mirrors models.ResumeContent with lenient field types for decoding model output
+type rawResumeContent struct {
+	PersonalInfo models.PersonalInfo `json:"personalInfo"`
+	Summary      string              `json:"summary"`
+	Experience   []rawExperience     `json:"experience"`
+	Education    []rawEducation      `json:"education"`
+	Skills       flexibleStrings     `json:"skills"`
+	Projects     []rawProject        `json:"projects"`
+}
+
+type rawExperience struct {
+	Company     string          `json:"company"`
+	Title       string          `json:"title"`
+	StartDate   string          `json:"startDate"`
+	EndDate     string          `json:"endDate"`
+	Current     bool            `json:"current"`
+	Location    string          `json:"location"`
+	Description flexibleStrings `json:"description"`
+	Source      flexibleStrings `json:"source"`
+}
+
+type rawEducation struct {
+	School      string `json:"school"`
+	Institution string `json:"institution"` // Alias some models use for school
+	Degree      string `json:"degree"`
+	Field       string `json:"field"`
+	Location    string `json:"location"`
+	StartDate   string `json:"startDate"`
+	EndDate     string `json:"endDate"`
+	Current     bool   `json:"current"`
+	Description string `json:"description"`
+}
+
+type rawProject struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Technologies flexibleStrings `json:"technologies"`
+	URL          string          `json:"url"`
+}
+
+// flexibleStrings decodes either a JSON array of strings or a single (possibly multi-line) string
+type flexibleStrings []string
+
+func (f *flexibleStrings) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*f = cleanStrings(list)
+		return nil
+	}
+
+	var single string
+	if err := json.Unmarshal(data, &single); err != nil {
+		return errors.New("expected a string or a list of strings")
+	}
+	*f = cleanStrings(strings.Split(single, "\n"))
+	return nil
+}
+
+// cleanStrings trims whitespace and bullet markers and drops empty entries
+func cleanStrings(items []string) []string {
+	cleaned := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(item), "-*•"))
+		if item != "" {
+			cleaned = append(cleaned, item)
+		}
+	}
+	return cleaned
+}
+
+// splitCommaList splits entries such as "Go, Python" into separate items
+func splitCommaList(items []string) []string {
+	var result []string
+	for _, item := range items {
+		result = append(result, cleanStrings(strings.Split(item, ","))...)
+	}
+	return result
+}
+
+var (
+	thinkBlockPattern    = regexp.MustCompile(`(?s)<think>.*?</think>`)
+	trailingCommaPattern = regexp.MustCompile(`,\s*([}\]])`)
+)
+
+// repairJSON extracts the JSON object from model output, dropping reasoning blocks,
+// code fences and surrounding prose, and removing trailing commas
+func repairJSON(output string) string {
+	output = thinkBlockPattern.ReplaceAllString(output, "")
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start == -1 || end < start {
+		return strings.TrimSpace(output)
+	}
+	return trailingCommaPattern.ReplaceAllString(output[start:end+1], "$1")
+}
+
+// parseResumeContent repairs, decodes and validates model output into a ResumeContent
+func parseResumeContent(output string) (*models.ResumeContent, error) {
+	var raw rawResumeContent
+	if err := json.Unmarshal([]byte(repairJSON(output)), &raw); err != nil {
+		return nil, fmt.Errorf("malformed JSON: %v", err)
+	}
+
+	var problems []string
+	content := &models.ResumeContent{
+		PersonalInfo: raw.PersonalInfo,
+		Summary:      strings.TrimSpace(raw.Summary),
+		Skills:       splitCommaList(raw.Skills),
+	}
+	if content.Summary == "" {
+		problems = append(problems, "summary is empty")
+	}
+
+	for i, exp := range raw.Experience {
+		if exp.Company == "" || exp.Title == "" {
+			problems = append(problems, fmt.Sprintf("experience[%d] needs a company and a title", i))
+			continue
+		}
+		start, err := parseFlexibleDate(exp.StartDate)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("experience[%d].startDate: %v", i, err))
+			continue
+		}
+		end, current, err := parseEndDate(exp.EndDate, exp.Current)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("experience[%d].endDate: %v", i, err))
+			continue
+		}
+		content.Experience = append(content.Experience, models.Experience{
+			Company:     strings.TrimSpace(exp.Company),
+			Title:       strings.TrimSpace(exp.Title),
+			StartDate:   start,
+			EndDate:     end,
+			Current:     current,
+			Location:    strings.TrimSpace(exp.Location),
+			Description: exp.Description,
+			Source:      strings.Join(exp.Source, ", "),
+		})
+	}
+
+	for i, edu := range raw.Education {
+		school := edu.School
+		if school == "" {
+			school = edu.Institution
+		}
+		if school == "" || edu.Degree == "" {
+			problems = append(problems, fmt.Sprintf("education[%d] needs a school and a degree", i))
+			continue
+		}
+		start, err := parseFlexibleDate(edu.StartDate)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("education[%d].startDate: %v", i, err))
+			continue
+		}
+		end, current, err := parseEndDate(edu.EndDate, edu.Current)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("education[%d].endDate: %v", i, err))
+			continue
+		}
+		content.Education = append(content.Education, models.Education{
+			School:      strings.TrimSpace(school),
+			Degree:      strings.TrimSpace(edu.Degree),
+			Field:       strings.TrimSpace(edu.Field),
+			Location:    strings.TrimSpace(edu.Location),
+			StartDate:   start,
+			EndDate:     end,
+			IsCurrent:   current,
+			Description: strings.TrimSpace(edu.Description),
+		})
+	}
+
+	for _, project := range raw.Projects {
+		if strings.TrimSpace(project.Name) == "" {
+			continue
+		}
+		content.Projects = append(content.Projects, models.Project{
+			Name:         strings.TrimSpace(project.Name),
+			Description:  strings.TrimSpace(project.Description),
+			Technologies: project.Technologies,
+			URL:          strings.TrimSpace(project.URL),
+		})
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	return content, nil
+}
+
+// flexibleDateLayouts are the date formats accepted from model output
+var flexibleDateLayouts = []string{
+	"2006-01",
+	"2006-01-02",
+	time.RFC3339,
+	"Jan 2006",
+	"January 2006",
+	"01/2006",
+	"2006",
+}
+
+// parseFlexibleDate parses a date in any of the layouts models commonly produce
+func parseFlexibleDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range flexibleDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// parseEndDate parses an optional end date, treating empty values and "Present" as ongoing
+func parseEndDate(value string, current bool) (*time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if current || value == "" || strings.EqualFold(value, "present") || strings.EqualFold(value, "current") {
+		return nil, true, nil
+	}
+	end, err := parseFlexibleDate(value)
+	if err != nil {
+		return nil, false, err
+	}
+	return &end, false, nil
+}
+
+// applyPersonalInfo overwrites the contact details of generated content with the user's profile
+func applyPersonalInfo(content *models.ResumeContent, user *models.User) {
+	content.PersonalInfo.Name = user.FullName
+	content.PersonalInfo.Email = user.Email
+	content.PersonalInfo.Phone = user.Phone
+	if content.PersonalInfo.Address == "" {
+		content.PersonalInfo.Address = user.Location
+	}
+	for i := range content.Education {
+		content.Education[i].UserID = user.ID
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repotest"
+)
+
+// busyOnRepairLLM returns invalid JSON for a generation and finds the queue full when asked
+// to repair it
+type busyOnRepairLLM struct {
+	*FakeLLMProvider
+}
+
+func (p busyOnRepairLLM) GenerateContent(ctx context.Context, req GenerationRequest) (string, error) {
+	if len(p.Requests()) > 0 {
+		p.record(req)
+		return "", ErrLLMQueueFull
+	}
+	return p.FakeLLMProvider.GenerateContent(ctx, req)
+}
+
+func TestGenerateStructuredResumeRepairQueueFull(t *testing.T) {
+	llm := busyOnRepairLLM{&FakeLLMProvider{Response: `{"summary": `}}
+	s, _ := newTestResumeService(t, llm)
+
+	_, _, err := s.GenerateStructuredResume(context.Background(), GenerateResumeParams{
+		UserID:         1,
+		JobDescription: repotest.JobDescription,
+	})
+	if !errors.Is(err, ErrLLMQueueFull) {
+		t.Fatalf("got error %v, want %v", err, ErrLLMQueueFull)
+	}
+	if len(llm.Requests()) != 2 {
+		t.Errorf("got %d LLM requests, want the generation and a repair", len(llm.Requests()))
+	}
+}
+
+func TestNormalizeResumeContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"complete", `{"summary": "Backend engineer", "experience": [{"company": "Acme Corp", "title": "Engineer", "startDate": "2021-03-01T00:00:00Z", "current": true}]}`, true},
+		{"empty summary", `{"summary": "", "skills": ["Go"]}`, true},
+		{"experience without start date", `{"summary": "Backend engineer", "experience": [{"company": "Acme Corp", "title": "Engineer"}]}`, true},
+		{"empty document", `{}`, true},
+		{"malformed", `{"summary": `, false},
+		{"wrong type", `{"skills": "Go"}`, false},
+		{"invalid date", `{"experience": [{"startDate": "last spring"}]}`, false},
+		{"null", `null`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resume := &models.Resume{Format: models.ResumeFormatJSON, Content: tt.content}
+			err := normalizeResumeContent(resume)
+			if tt.valid && err != nil {
+				t.Fatalf("normalizeResumeContent: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidResumeContent) {
+				t.Fatalf("got error %v, want %v", err, ErrInvalidResumeContent)
+			}
+		})
+	}
+}
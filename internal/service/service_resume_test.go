@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repotest"
+)
+
+// newTestResumeService returns a resume service generating with llm on in-memory
+// repositories holding repotest.User, and the repository of the resumes it saves
+func newTestResumeService(t *testing.T, llm LLMProvider) (*ResumeService, *repotest.Resumes) {
+	t.Helper()
+	users := repotest.NewUsers(repotest.User())
+	resumes := repotest.NewResumes(users)
+
+	keywordService := NewKeywordService(nil, repotest.NewKeywords(), repotest.NewSkills(), config.NewKeywordConfig(), NewHashingEmbedder(64))
+	userService := NewUserService(users, nil)
+	llmConfig := &config.LLMConfig{Model: "fake", VerifyMode: config.VerifyModeFlag}
+	return NewResumeService(nil, resumes, keywordService, llm, userService, llmConfig), resumes
+}
+
+// streamRecorder collects the chunks of a generation with the time they arrived
+type streamRecorder struct {
+	chunks []string
+	times  []time.Time
+	done   int
+}
+
+func (r *streamRecorder) handle(chunk string, done bool) error {
+	r.chunks = append(r.chunks, chunk)
+	r.times = append(r.times, time.Now())
+	if done {
+		r.done++
+	}
+	return nil
+}
+
+func (r *streamRecorder) content() string {
+	return strings.Join(r.chunks, "")
+}
+
+func TestGenerateResumeStreamsAndSaves(t *testing.T) {
+	llm := &FakeLLMProvider{Response: repotest.Resume}
+	s, resumes := newTestResumeService(t, llm)
+
+	var stream streamRecorder
+	resume, err := s.GenerateResume(context.Background(), GenerateResumeParams{
+		UserID:         1,
+		JobDescription: repotest.JobDescription,
+	}, stream.handle)
+	if err != nil {
+		t.Fatalf("GenerateResume: %v", err)
+	}
+
+	if stream.done != 1 {
+		t.Errorf("got %d done chunks, want 1", stream.done)
+	}
+	if len(stream.chunks) < 10 {
+		t.Errorf("got %d chunks, want the resume streamed word by word", len(stream.chunks))
+	}
+	if strings.Contains(stream.content(), "[src:") || strings.Contains(stream.content(), "WE-12") {
+		t.Errorf("source markers were streamed:\n%s", stream.content())
+	}
+	if strings.TrimSpace(stream.content()) != strings.TrimSpace(resume.Content) {
+		t.Errorf("streamed content differs from the saved resume:\nstreamed:\n%s\nsaved:\n%s", stream.content(), resume.Content)
+	}
+
+	if len(resume.Findings) > 0 {
+		t.Errorf("got findings %+v for a resume matching the profile", resume.Findings)
+	}
+	if len(resume.Provenance) != 3 {
+		t.Fatalf("got %d bullet points with provenance, want 3: %+v", len(resume.Provenance), resume.Provenance)
+	}
+	for _, bullet := range resume.Provenance {
+		if bullet.Location == "" || len(bullet.Sources) != 1 {
+			t.Errorf("bullet %q has location %q and sources %+v", bullet.Text, bullet.Location, bullet.Sources)
+		}
+	}
+
+	saved := resumes.All()
+	if len(saved) != 1 {
+		t.Fatalf("got %d saved resumes, want 1", len(saved))
+	}
+	if saved[0].Content != resume.Content {
+		t.Errorf("saved content %q, want %q", saved[0].Content, resume.Content)
+	}
+
+	requests := llm.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d LLM requests, want 1", len(requests))
+	}
+	if requests[0].System != resumeSystemPrompt {
+		t.Errorf("request has system prompt %q", requests[0].System)
+	}
+	if !strings.Contains(requests[0].Prompt, repotest.JobDescription) || !strings.Contains(requests[0].Prompt, "[WE-12]") {
+		t.Errorf("prompt lacks the job description or the labeled profile:\n%s", requests[0].Prompt)
+	}
+}
+
+func TestGenerateResumeMidStreamFailure(t *testing.T) {
+	llm := &FakeLLMProvider{Response: repotest.Resume, FailAfter: 5}
+	s, resumes := newTestResumeService(t, llm)
+
+	var stream streamRecorder
+	resume, err := s.GenerateResume(context.Background(), GenerateResumeParams{
+		UserID:         1,
+		JobDescription: repotest.JobDescription,
+	}, stream.handle)
+	if err == nil || !strings.Contains(err.Error(), ErrFakeLLMFailure.Error()) {
+		t.Fatalf("got resume %v and error %v, want %v", resume, err, ErrFakeLLMFailure)
+	}
+
+	if len(stream.chunks) == 0 || len(stream.chunks) > 5 {
+		t.Errorf("got %d chunks before the failure, want 1 to 5", len(stream.chunks))
+	}
+	if stream.done != 0 {
+		t.Errorf("got %d done chunks for a failed stream", stream.done)
+	}
+	if saved := resumes.All(); len(saved) != 0 {
+		t.Errorf("saved %d resumes after a failed stream", len(saved))
+	}
+}
+
+func TestGenerateResumeStreamsWithLatency(t *testing.T) {
+	const latency = 5 * time.Millisecond
+	llm := &FakeLLMProvider{Response: repotest.Resume, ChunkSize: 8, Latency: latency}
+	s, _ := newTestResumeService(t, llm)
+
+	var stream streamRecorder
+	start := time.Now()
+	if _, err := s.GenerateResume(context.Background(), GenerateResumeParams{
+		UserID:         1,
+		JobDescription: repotest.JobDescription,
+	}, stream.handle); err != nil {
+		t.Fatalf("GenerateResume: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	chunks := len(SplitChunks(repotest.Resume, 8))
+	if elapsed < time.Duration(chunks)*latency {
+		t.Errorf("generation took %v, want at least %d chunks of %v", elapsed, chunks, latency)
+	}
+	// Chunks are handed on as they arrive rather than after the whole response
+	if len(stream.times) < 2 || stream.times[len(stream.times)-1].Sub(stream.times[0]) < latency {
+		t.Errorf("chunks were not streamed as they were generated")
+	}
+}
+
+func TestGenerateResumeCanceledWhileStreaming(t *testing.T) {
+	llm := &FakeLLMProvider{Response: repotest.Resume, Latency: 20 * time.Millisecond}
+	s, resumes := newTestResumeService(t, llm)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 70*time.Millisecond)
+	defer cancel()
+	var stream streamRecorder
+	_, err := s.GenerateResume(ctx, GenerateResumeParams{
+		UserID:         1,
+		JobDescription: repotest.JobDescription,
+	}, stream.handle)
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Fatalf("got error %v, want the deadline to interrupt the stream", err)
+	}
+	if saved := resumes.All(); len(saved) != 0 {
+		t.Errorf("saved %d resumes after the deadline", len(saved))
+	}
+}
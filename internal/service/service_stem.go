@@ -0,0 +1,244 @@
+package service
+
+import (
+	"sort"
+	"strings"
+)
+
+// stem reduces an English word to its stem with the Porter algorithm, so that e.g.
+// "develop", "developing" and "developer" all become "develop". Stems are not always words;
+// they only serve to group terms. Words with characters other than a to z are returned as
+// they are.
+func stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	w := []byte(word)
+	w = stemStep1a(w)
+	w = stemStep1b(w)
+	w = stemStep1c(w)
+	w = replaceLongestSuffix(w, stemStep2Rules, 0)
+	w = replaceLongestSuffix(w, stemStep3Rules, 0)
+	w = stemStep4(w)
+	w = stemStep5(w)
+	return string(w)
+}
+
+// stemPhrase stems each word of a phrase
+func stemPhrase(phrase string) string {
+	words := strings.Fields(phrase)
+	for i, word := range words {
+		words[i] = stem(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// suffixRule replaces a suffix of a word
+type suffixRule struct {
+	suffix      string
+	replacement string
+}
+
+// Suffix rules of steps 2 to 4, longest suffix first since the longest matching suffix decides
+var (
+	stemStep2Rules = sortedSuffixRules([]suffixRule{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+		{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+		{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+		{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+		{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+		{"logi", "log"},
+	})
+	stemStep3Rules = sortedSuffixRules([]suffixRule{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+		{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+	})
+	stemStep4Rules = sortedSuffixRules([]suffixRule{
+		{"al", ""}, {"ance", ""}, {"ence", ""}, {"er", ""}, {"ic", ""},
+		{"able", ""}, {"ible", ""}, {"ant", ""}, {"ement", ""}, {"ment", ""},
+		{"ent", ""}, {"ion", ""}, {"ou", ""}, {"ism", ""}, {"ate", ""},
+		{"iti", ""}, {"ous", ""}, {"ive", ""}, {"ize", ""},
+	})
+)
+
+// sortedSuffixRules orders rules by descending suffix length
+func sortedSuffixRules(rules []suffixRule) []suffixRule {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].suffix) > len(rules[j].suffix)
+	})
+	return rules
+}
+
+// stemStep1a removes plural endings: "caresses" → "caress", "ponies" → "poni", "cats" → "cat"
+func stemStep1a(w []byte) []byte {
+	switch {
+	case hasSuffix(w, "sses"), hasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// stemStep1b removes "ed" and "ing": "agreed" → "agree", "hopping" → "hop", "filing" → "file"
+func stemStep1b(w []byte) []byte {
+	if hasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem []byte
+	switch {
+	case hasSuffix(w, "ed") && containsVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case hasSuffix(w, "ing") && containsVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return append(stem, 'e')
+	case endsWithDoubleConsonant(stem):
+		if last := stem[len(stem)-1]; last != 'l' && last != 's' && last != 'z' {
+			return stem[:len(stem)-1]
+		}
+	case measure(stem) == 1 && endsWithCVC(stem):
+		return append(stem, 'e')
+	}
+	return stem
+}
+
+// stemStep1c turns a final "y" after a vowel into "i": "happy" → "happi"
+func stemStep1c(w []byte) []byte {
+	if hasSuffix(w, "y") && containsVowel(w[:len(w)-1]) {
+		w[len(w)-1] = 'i'
+	}
+	return w
+}
+
+// stemStep4 removes suffixes such as "ment" or "er" from stems with more than one syllable
+func stemStep4(w []byte) []byte {
+	for _, rule := range stemStep4Rules {
+		if !hasSuffix(w, rule.suffix) {
+			continue
+		}
+		stem := w[:len(w)-len(rule.suffix)]
+		if measure(stem) <= 1 {
+			return w
+		}
+		if rule.suffix == "ion" && !hasSuffix(stem, "s") && !hasSuffix(stem, "t") {
+			return w
+		}
+		return stem
+	}
+	return w
+}
+
+// stemStep5 removes a final "e" and reduces a final "ll": "probate" → "probat", "controll" → "control"
+func stemStep5(w []byte) []byte {
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := measure(stem); m > 1 || (m == 1 && !endsWithCVC(stem)) {
+			w = stem
+		}
+	}
+	if hasSuffix(w, "ll") && measure(w) > 1 {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// replaceLongestSuffix applies the rule with the longest suffix of the word, provided the
+// rest of the word has a measure above minMeasure
+func replaceLongestSuffix(w []byte, rules []suffixRule, minMeasure int) []byte {
+	for _, rule := range rules {
+		if !hasSuffix(w, rule.suffix) {
+			continue
+		}
+		stem := w[:len(w)-len(rule.suffix)]
+		if measure(stem) <= minMeasure {
+			return w
+		}
+		return append(stem, rule.replacement...)
+	}
+	return w
+}
+
+// hasSuffix reports whether a word ends with suffix
+func hasSuffix(w []byte, suffix string) bool {
+	return len(w) >= len(suffix) && string(w[len(w)-len(suffix):]) == suffix
+}
+
+// isConsonant reports whether the letter at i is a consonant. "y" is a consonant unless it
+// follows one.
+func isConsonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences of a stem, roughly its syllables:
+// 0 for "tree", 1 for "trouble", 2 for "troubles"
+func measure(w []byte) int {
+	m := 0
+	i := 0
+	for i < len(w) && isConsonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !isConsonant(w, i) {
+			i++
+		}
+		if i == len(w) {
+			break
+		}
+		for i < len(w) && isConsonant(w, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+// containsVowel reports whether a stem contains a vowel
+func containsVowel(w []byte) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsWithDoubleConsonant reports whether a stem ends with a doubled consonant such as "tt"
+func endsWithDoubleConsonant(w []byte) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsWithCVC reports whether a stem ends consonant-vowel-consonant with a final consonant
+// other than "w", "x" or "y", as in "hop" but not "snow"
+func endsWithCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-3) || isConsonant(w, n-2) || !isConsonant(w, n-1) {
+		return false
+	}
+	last := w[n-1]
+	return last != 'w' && last != 'x' && last != 'y'
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Languages with bundled stopword lists
+const (
+	LanguageEnglish = "en"
+	LanguageGerman  = "de"
+	LanguageFrench  = "fr"
+	LanguageSpanish = "es"
+)
+
+// stopwordLanguages lists the supported languages; detection prefers earlier ones on ties
+var stopwordLanguages = []string{LanguageEnglish, LanguageGerman, LanguageFrench, LanguageSpanish}
+
+//go:embed data/stopwords/*.txt
+var stopwordFiles embed.FS
+
+// stopwords holds the stopwords of each language: the bundled lists, extended by
+// AddStopwordsFile
+var stopwords = mustLoadStopwords()
+
+// mustLoadStopwords reads the bundled stopword lists and panics when one is missing
+func mustLoadStopwords() map[string]map[string]bool {
+	lists := make(map[string]map[string]bool, len(stopwordLanguages))
+	for _, lang := range stopwordLanguages {
+		f, err := stopwordFiles.Open("data/stopwords/" + lang + ".txt")
+		if err != nil {
+			panic(fmt.Sprintf("missing stopword list: %v", err))
+		}
+		lists[lang] = make(map[string]bool)
+		for _, word := range readWordList(f) {
+			lists[lang][word] = true
+		}
+		f.Close()
+	}
+	return lists
+}
+
+// readWordList returns the words of a list with one word per line. Blank lines and lines
+// starting with # are skipped.
+func readWordList(r io.Reader) []string {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words
+}
+
+// AddStopwordsFile extends the stopword lists with the words of a file, one per line. Words
+// apply to all languages unless prefixed with a language, e.g. "de: bewerbung". It must be
+// called before keywords are extracted, since the lists are not guarded for concurrent use.
+func AddStopwordsFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open stopwords file: %v", err)
+	}
+	defer f.Close()
+
+	for _, line := range readWordList(f) {
+		langs := stopwordLanguages
+		if lang, word, ok := strings.Cut(line, ":"); ok {
+			lang = strings.TrimSpace(lang)
+			if stopwords[lang] == nil {
+				return fmt.Errorf("unsupported stopword language %q in %s", lang, path)
+			}
+			langs = []string{lang}
+			line = strings.TrimSpace(word)
+		}
+		for _, lang := range langs {
+			stopwords[lang][line] = true
+		}
+	}
+	return nil
+}
+
+// elisions are the French articles and pronouns that are elided before a vowel, as in
+// "l'entreprise" or "qu'il"
+var elisions = map[string]bool{"l": true, "d": true, "qu": true, "j": true, "n": true, "s": true, "c": true}
+
+// splitElisions splits elided articles and pronouns off the following word, with a straight
+// or curly apostrophe, so that "l'équipe" becomes "l" and "équipe" and both are looked up
+// as they are: the article as a stopword, the word as a keyword
+func splitElisions(tokens []string) []string {
+	out := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "’", "'")
+		if prefix, word, ok := strings.Cut(token, "'"); ok && word != "" && elisions[prefix] {
+			out = append(out, prefix, word)
+			continue
+		}
+		out = append(out, token)
+	}
+	return out
+}
+
+// detectLanguage returns the language whose stopwords occur most often among the words of a
+// text, English when none occur
+func detectLanguage(tokens []string) string {
+	best, bestHits := LanguageEnglish, 0
+	for _, lang := range stopwordLanguages {
+		hits := 0
+		for _, token := range tokens {
+			if stopwords[lang][token] {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = lang, hits
+		}
+	}
+	return best
+}
+
+// DetectLanguage returns the language of a text, as used for keyword extraction
+func (s *KeywordService) DetectLanguage(text string) string {
+	return detectLanguage(taxonomy.normalize(text, commonPhraseSet))
+}
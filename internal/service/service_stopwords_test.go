@@ -0,0 +1,17 @@
+package service
+
+import "testing"
+
+func TestGermanStopwords(t *testing.T) {
+	text := "Wir suchen für unser Team eine erfahrene Backend-Entwicklerin. Unser Team arbeitet mit Go und Kubernetes."
+	opts := DefaultKeywordOptions()
+	opts.Language = LanguageGerman
+
+	for _, k := range extractKeywords(text, opts) {
+		for _, word := range []string{"unser", "unser team", "für", "wir"} {
+			if k.Term == word {
+				t.Errorf("got keyword %q of stopwords", k.Term)
+			}
+		}
+	}
+}
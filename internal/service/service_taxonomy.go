@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+//go:embed data/skill_taxonomy.json
+var skillTaxonomyData []byte
+
+// skillTokenPrefix starts the placeholder tokens known skills are replaced with during
+// extraction. Extraction works on lowercase text, so the uppercase prefix cannot collide.
+const skillTokenPrefix = "SKILL"
+
+// taxonomy is the skill taxonomy bundled with the service
+var taxonomy = mustLoadSkillTaxonomy(skillTaxonomyData)
+
+// TaxonomySkill is a skill of the taxonomy with the terms that refer to it
+type TaxonomySkill struct {
+	Name     string   `json:"name"`     // Canonical name, e.g. "Kubernetes"
+	Category string   `json:"category"` // e.g. "DevOps"
+	Aliases  []string `json:"aliases"`  // Lowercase terms that mean the skill, e.g. "k8s"
+
+	// Terms that mean the skill only when written as they are, e.g. "Go" but not "go"
+	CaseSensitiveAliases []string `json:"caseSensitiveAliases,omitempty"`
+}
+
+// Term returns the keyword the skill is counted as
+func (s *TaxonomySkill) Term() string {
+	return strings.ToLower(s.Name)
+}
+
+// skillTaxonomy maps the aliases of skills to their canonical names
+type skillTaxonomy struct {
+	skills   []TaxonomySkill
+	terms    map[string]int // Aliases without punctuation, by their stemmed words joined with single spaces
+	symbols  []skillAlias   // Aliases with punctuation such as "c++" or "node.js", longest first
+	maxWords int            // Words of the longest alias in terms
+
+	caseSensitive      *regexp.Regexp // Matches the case-sensitive aliases as whole words; nil when there are none
+	caseSensitiveTerms map[string]int // Case-sensitive aliases as written
+}
+
+// skillAlias is an alias that is matched in the text before punctuation is removed
+type skillAlias struct {
+	alias string
+	skill int
+}
+
+// mustLoadSkillTaxonomy parses the bundled taxonomy and panics when it is invalid
+func mustLoadSkillTaxonomy(data []byte) *skillTaxonomy {
+	t, err := loadSkillTaxonomy(data)
+	if err != nil {
+		panic(fmt.Sprintf("invalid skill taxonomy: %v", err))
+	}
+	return t
+}
+
+// loadSkillTaxonomy parses a taxonomy. An alias may refer to one skill only.
+func loadSkillTaxonomy(data []byte) (*skillTaxonomy, error) {
+	var skills []TaxonomySkill
+	if err := json.Unmarshal(data, &skills); err != nil {
+		return nil, err
+	}
+
+	t := &skillTaxonomy{
+		skills:             skills,
+		terms:              make(map[string]int),
+		caseSensitiveTerms: make(map[string]int),
+	}
+	names := make(map[string]bool)
+	seen := make(map[string]string)
+	for i, skill := range skills {
+		if strings.TrimSpace(skill.Name) == "" {
+			return nil, errors.New("skill without name")
+		}
+		if names[skill.Term()] {
+			return nil, fmt.Errorf("duplicate skill %q", skill.Name)
+		}
+		names[skill.Term()] = true
+
+		for _, alias := range skill.Aliases {
+			alias = strings.ToLower(strings.TrimSpace(alias))
+			if alias == "" {
+				continue
+			}
+
+			// Punctuation is significant in aliases such as "c++", which cleaning reduces to "c",
+			// so they are matched as written; other aliases match by their stems
+			symbolic := cleanText(alias) != alias
+			key := alias
+			if !symbolic {
+				key = stemPhrase(alias)
+			}
+			if other, ok := seen[key]; ok && other != skill.Name {
+				return nil, fmt.Errorf("alias %q of %q already refers to %q", alias, skill.Name, other)
+			}
+			seen[key] = skill.Name
+
+			if symbolic {
+				t.symbols = append(t.symbols, skillAlias{alias: alias, skill: i})
+				continue
+			}
+			t.terms[key] = i
+			t.maxWords = max(t.maxWords, len(strings.Fields(key)))
+		}
+
+		for _, alias := range skill.CaseSensitiveAliases {
+			alias = strings.TrimSpace(alias)
+			if alias == "" {
+				continue
+			}
+			if other, ok := t.caseSensitiveTerms[alias]; ok && other != i {
+				return nil, fmt.Errorf("alias %q of %q already refers to %q", alias, skill.Name, skills[other].Name)
+			}
+			t.caseSensitiveTerms[alias] = i
+		}
+	}
+
+	if len(t.caseSensitiveTerms) > 0 {
+		aliases := make([]string, 0, len(t.caseSensitiveTerms))
+		for alias := range t.caseSensitiveTerms {
+			aliases = append(aliases, regexp.QuoteMeta(alias))
+		}
+		// Longest first, so that the longest alias wins where one contains another
+		sort.Slice(aliases, func(i, j int) bool {
+			if len(aliases[i]) != len(aliases[j]) {
+				return len(aliases[i]) > len(aliases[j])
+			}
+			return aliases[i] < aliases[j]
+		})
+		// A whole word that is not part of a hyphenated compound such as "Go-to-market"
+		t.caseSensitive = regexp.MustCompile(`(?:^|[^\p{L}\p{N}-])(` + strings.Join(aliases, "|") + `)(?:$|[^\p{L}\p{N}-])`)
+	}
+	sort.SliceStable(t.symbols, func(i, j int) bool {
+		return len(t.symbols[i].alias) > len(t.symbols[j].alias)
+	})
+	return t, nil
+}
+
+// normalize lowercases a text, splits it into words without punctuation and replaces the
+// aliases of skills with placeholder tokens, longest alias first, so that e.g. "k8s" and
+// "kubernetes orchestration" both count as the skill Kubernetes. Case-sensitive aliases
+// such as "Go" are replaced before the text is lowercased. Aliases match regardless
+// of inflection. Elided French articles are split off their words first. Phrases whose
+// stems are in keep are left as they are, even where they contain an alias.
+func (t *skillTaxonomy) normalize(text string, keep map[string]bool) []string {
+	text = t.lower(text)
+	for _, s := range t.symbols {
+		text = replaceWholeTerm(text, s.alias, " "+skillToken(s.skill)+" ")
+	}
+
+	maxWords := t.maxWords
+	for phrase := range keep {
+		maxWords = max(maxWords, len(strings.Fields(phrase)))
+	}
+
+	tokens := splitElisions(strings.Fields(cleanText(text)))
+	stems := make([]string, len(tokens))
+	for i, token := range tokens {
+		stems[i] = stem(token)
+	}
+
+	out := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); {
+		n := min(maxWords, len(tokens)-i)
+		for ; n > 0; n-- {
+			key := strings.Join(stems[i:i+n], " ")
+			if keep[key] {
+				out = append(out, tokens[i:i+n]...)
+				break
+			}
+			if skill, ok := t.terms[key]; ok {
+				out = append(out, skillToken(skill))
+				break
+			}
+		}
+		if n == 0 {
+			out = append(out, tokens[i])
+			n = 1
+		}
+		i += n
+	}
+	return out
+}
+
+// lower lowercases a text, replacing the case-sensitive aliases of skills with placeholder
+// tokens first, since their lowercase forms are common words: "Go" is the language, "go" is not
+func (t *skillTaxonomy) lower(text string) string {
+	if t.caseSensitive == nil {
+		return strings.ToLower(text)
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, m := range t.caseSensitive.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2], m[3]
+		out.WriteString(strings.ToLower(text[last:start]))
+		out.WriteString(" " + skillToken(t.caseSensitiveTerms[text[start:end]]) + " ")
+		last = end
+	}
+	out.WriteString(strings.ToLower(text[last:]))
+	return out.String()
+}
+
+// isSkillToken reports whether a token is the placeholder of a skill
+func isSkillToken(token string) bool {
+	_, ok := taxonomy.skill(token)
+	return ok
+}
+
+// skill returns the skill a placeholder token stands for
+func (t *skillTaxonomy) skill(token string) (*TaxonomySkill, bool) {
+	if !strings.HasPrefix(token, skillTokenPrefix) {
+		return nil, false
+	}
+	i, err := strconv.Atoi(token[len(skillTokenPrefix):])
+	if err != nil || i < 0 || i >= len(t.skills) {
+		return nil, false
+	}
+	return &t.skills[i], true
+}
+
+// skillToken returns the placeholder token of the skill at index i
+func skillToken(i int) string {
+	return skillTokenPrefix + strconv.Itoa(i)
+}
+
+// replaceWholeTerm replaces the occurrences of term in text that are not part of a longer
+// word, e.g. "c++" in "c++ and go" but not in "c++17"
+func replaceWholeTerm(text, term, replacement string) string {
+	var out strings.Builder
+	last := 0
+	for from := 0; ; {
+		i := strings.Index(text[from:], term)
+		if i < 0 {
+			break
+		}
+		start, end := from+i, from+i+len(term)
+		if (start == 0 || !isWordByte(text[start-1])) && (end == len(text) || !isWordByte(text[end])) {
+			out.WriteString(text[last:start])
+			out.WriteString(replacement)
+			last = end
+		}
+		from = end
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// isWordByte reports whether a byte is part of a word. Bytes of multibyte characters are
+// treated as letters.
+func isWordByte(b byte) bool {
+	return b >= 0x80 || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
+
+// SeedSkills adds the skills of the taxonomy with their categories to the skills table.
+// Existing skills keep their category unless they have none.
+func (s *KeywordService) SeedSkills(ctx context.Context) error {
+	skills := make([]models.Skill, 0, len(taxonomy.skills))
+	for _, skill := range taxonomy.skills {
+		skills = append(skills, models.Skill{Name: skill.Name, Category: skill.Category})
+	}
+	if err := s.skillRepo.UpsertSkills(ctx, skills); err != nil {
+		return fmt.Errorf("failed to seed skills: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaxonomyAmbiguousAliases(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string // Skills the text mentions
+	}{
+		{"You react quickly to incidents. Reacting fast matters.", nil},
+		{"You spark ideas and deliver swift fixes.", nil},
+		{"Experience with version control and a rusty helm.", nil},
+		{"Build frontends in React and pipelines with Spark.", []string{"React", "Spark"}},
+		{"We ship Rust services with Helm charts, and Flask and Swift apps.", []string{"Rust", "Helm", "Flask", "Swift"}},
+		{"Experience with reactjs and git.", []string{"React", "Git"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, token := range taxonomy.normalize(tt.text, nil) {
+			if skill, ok := taxonomy.skill(token); ok {
+				got = append(got, skill.Name)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q mentions %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+)
+
+// profileFacts holds the normalized values of a profile that generated resumes may mention
+type profileFacts struct {
+	user *models.User
+
+	companies  []string
+	titles     []string
+	schools    []string
+	degrees    []string        // Degrees and fields of study
+	locations  map[string]bool // Places and their parts, e.g. "berlin germany", "berlin" and "germany"
+	years      map[int]bool
+	monthYears map[string]bool // "2021-03"
+}
+
+// newProfileFacts collects the facts of a profile
+func newProfileFacts(user *models.User) *profileFacts {
+	facts := &profileFacts{
+		user:       user,
+		locations:  make(map[string]bool),
+		years:      make(map[int]bool),
+		monthYears: make(map[string]bool),
+	}
+	addLocation := func(location string) {
+		if location = normalizeFact(location); location != "" {
+			facts.locations[location] = true
+			// "Berlin, Germany" also covers "Berlin" and "Germany"
+			for _, part := range strings.Split(location, " ") {
+				if len(part) > 2 {
+					facts.locations[part] = true
+				}
+			}
+		}
+	}
+	addLocation(user.Location)
+	if user.Title != "" {
+		facts.titles = append(facts.titles, normalizeFact(user.Title))
+	}
+
+	for _, exp := range user.WorkExperience {
+		facts.companies = append(facts.companies, normalizeFact(exp.Company))
+		facts.titles = append(facts.titles, normalizeFact(exp.Title))
+		addLocation(exp.Location)
+		facts.addPeriod(exp.StartDate, exp.EndDate, exp.IsCurrent)
+	}
+	for _, edu := range user.Education {
+		facts.schools = append(facts.schools, normalizeFact(edu.School))
+		facts.degrees = append(facts.degrees, normalizeFact(edu.Degree), normalizeFact(edu.Field))
+		addLocation(edu.Location)
+		facts.addPeriod(edu.StartDate, edu.EndDate, edu.IsCurrent)
+	}
+	return facts
+}
+
+// addPeriod records the dates of a profile record; ongoing records end today
+func (f *profileFacts) addPeriod(start time.Time, end *time.Time, current bool) {
+	dates := []time.Time{start}
+	if end != nil {
+		dates = append(dates, *end)
+	}
+	if current || end == nil {
+		dates = append(dates, time.Now())
+	}
+	for _, d := range dates {
+		f.years[d.Year()] = true
+		f.monthYears[d.Format("2006-01")] = true
+	}
+}
+
+// legalForms are words that may follow a company name without changing the company
+var legalForms = map[string]bool{
+	"inc": true, "llc": true, "ltd": true, "limited": true, "corp": true, "corporation": true,
+	"co": true, "company": true, "gmbh": true, "ag": true, "se": true, "plc": true, "sa": true,
+	"bv": true, "kg": true, "ug": true,
+}
+
+// isLocation reports whether text is a place of the profile
+func (f *profileFacts) isLocation(text string) bool {
+	return f.locations[normalizeFact(text)]
+}
+
+// knownEntity reports whether text names a profile value of one of the given lists. Values
+// are compared word by word: text may shorten a value, e.g. "Acme" for "Acme Corp", or add
+// legal forms and places of the profile, e.g. "Acme Corp GmbH" or "Acme Corp Berlin", but
+// other words, such as "Staff" before a title, make it a different entity.
+func (f *profileFacts) knownEntity(text string, lists ...[]string) bool {
+	text = normalizeFact(text)
+	if text == "" {
+		return true
+	}
+	words := strings.Fields(text)
+	for _, list := range lists {
+		for _, value := range list {
+			if value == "" {
+				continue
+			}
+			valueWords := strings.Fields(value)
+			if text == value || len(text) > 3 && indexWords(valueWords, words) >= 0 {
+				return true
+			}
+			if i := indexWords(words, valueWords); i >= 0 && f.onlyQualifiers(words[:i]) && f.onlyQualifiers(words[i+len(valueWords):]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// onlyQualifiers reports whether words are all legal forms or places of the profile
+func (f *profileFacts) onlyQualifiers(words []string) bool {
+	for _, word := range words {
+		if !legalForms[word] && !f.locations[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// indexWords returns the index of the first run of words in text equal to sub, or -1
+func indexWords(text, sub []string) int {
+	for i := 0; i+len(sub) <= len(text); i++ {
+		match := true
+		for j := range sub {
+			if text[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	factSeparatorPattern  = regexp.MustCompile(`[^\p{L}\p{N}+#&]+`)
+	emailPattern          = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern          = regexp.MustCompile(`\+?\(?\d[\d\s().-]{7,}\d`)
+	yearPattern           = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+	monthYearPattern      = regexp.MustCompile(`(?i)\b(jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?\s+((?:19|20)\d{2})\b|\b((?:19|20)\d{2})-(0[1-9]|1[0-2])\b|\b(0?[1-9]|1[0-2])/((?:19|20)\d{2})\b`)
+	entrySeparatorPattern = regexp.MustCompile(`\s*(?:\||•|·|—|–|\s-\s|,|\(|\)|\bat\b|\bin\b)\s*`)
+	presentPattern        = regexp.MustCompile(`(?i)^(present|current|now|today)$`)
+	// A bold line naming a title and a company, e.g. "**Engineer** at Acme" or "**Engineer | Acme**"
+	boldEntryPattern = regexp.MustCompile(`^(\*\*|__)\S.*?(\s+at\s+|\s*\|\s*)\S`)
+	// A bold label followed by a value, e.g. "**Technologies:** Go" or "**Skills**: Go"
+	labelLinePattern = regexp.MustCompile(`^(\*\*|__)[^*_]+?(:\s*(\*\*|__)|(\*\*|__)\s*:)`)
+)
+
+// normalizeFact lowercases a value and collapses punctuation so that formatting differences do not matter
+func normalizeFact(value string) string {
+	return strings.TrimSpace(factSeparatorPattern.ReplaceAllString(strings.ToLower(value), " "))
+}
+
+var monthNumbers = map[string]string{
+	"jan": "01", "feb": "02", "mar": "03", "apr": "04", "may": "05", "jun": "06",
+	"jul": "07", "aug": "08", "sep": "09", "sept": "09", "oct": "10", "nov": "11", "dec": "12",
+}
+
+// unknownDates returns the dates in text that no profile record has
+func (f *profileFacts) unknownDates(text string) []string {
+	var unknown []string
+	covered := make(map[string]bool)
+	for _, m := range monthYearPattern.FindAllStringSubmatch(text, -1) {
+		var key string
+		switch {
+		case m[1] != "":
+			key = m[2] + "-" + monthNumbers[strings.ToLower(m[1])]
+			covered[m[2]] = true
+		case m[3] != "":
+			key = m[3] + "-" + m[4]
+			covered[m[3]] = true
+		default:
+			month, _ := strconv.Atoi(m[5])
+			key = fmt.Sprintf("%s-%02d", m[6], month)
+			covered[m[6]] = true
+		}
+		if !f.monthYears[key] {
+			unknown = append(unknown, strings.TrimSpace(m[0]))
+		}
+	}
+	for _, year := range yearPattern.FindAllString(text, -1) {
+		if covered[year] {
+			continue
+		}
+		if y, _ := strconv.Atoi(year); !f.years[y] {
+			unknown = append(unknown, year)
+		}
+	}
+	return unknown
+}
+
+// phoneDigits returns the digits of a phone number
+func phoneDigits(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+// verifyMarkdown checks a generated markdown resume against the profile. Personal details
+// must match exactly; entries and dates of the experience and education sections must exist
+// in the profile. In strip mode offending lines are removed and personal details corrected.
+func verifyMarkdown(content string, user *models.User, mode string) (string, models.VerificationFindings) {
+	facts := newProfileFacts(user)
+	findings := models.VerificationFindings{}
+	action := models.FindingFlagged
+	if mode == config.VerifyModeStrip {
+		action = models.FindingStripped
+	}
+	fixed := func() string {
+		if mode == config.VerifyModeStrip {
+			return models.FindingCorrected
+		}
+		return models.FindingFlagged
+	}()
+
+	lines := strings.Split(content, "\n")
+	kept := make([]string, 0, len(lines))
+	section := ""
+	nameChecked := false
+	skipping := false // Inside the description of a stripped entry
+	for i, line := range lines {
+		location := fmt.Sprintf("line %d", i+1)
+		strip := false
+
+		// Personal details
+		if m := headingPattern.FindStringSubmatch(line); m != nil && len(m[1]) == 1 && !nameChecked {
+			nameChecked = true
+			if name := strings.Trim(m[2], "*_ "); name != user.FullName {
+				findings = append(findings, models.VerificationFinding{Kind: models.FindingName, Value: name, Expected: user.FullName, Location: location, Action: fixed})
+				if mode == config.VerifyModeStrip {
+					line = strings.Replace(line, name, user.FullName, 1)
+				}
+			}
+		}
+		for _, email := range emailPattern.FindAllString(line, -1) {
+			if !strings.EqualFold(email, user.Email) {
+				findings = append(findings, models.VerificationFinding{Kind: models.FindingEmail, Value: email, Expected: user.Email, Location: location, Action: fixed})
+				if mode == config.VerifyModeStrip {
+					line = strings.Replace(line, email, user.Email, 1)
+				}
+			}
+		}
+		for _, phone := range phonePattern.FindAllString(line, -1) {
+			// Shorter digit runs are dates or numbers in achievements
+			if len(phoneDigits(phone)) < 9 || yearPattern.MatchString(strings.TrimSpace(phone)) && len(phoneDigits(phone)) < 12 {
+				continue
+			}
+			if phoneDigits(phone) != phoneDigits(user.Phone) {
+				findings = append(findings, models.VerificationFinding{Kind: models.FindingPhone, Value: strings.TrimSpace(phone), Expected: user.Phone, Location: location, Action: fixed})
+				if mode == config.VerifyModeStrip && user.Phone != "" {
+					line = strings.Replace(line, strings.TrimSpace(phone), user.Phone, 1)
+				}
+			}
+		}
+
+		// Section headings switch the kind of entries checked below
+		if m := headingPattern.FindStringSubmatch(line); m != nil && len(m[1]) <= 2 {
+			section = resumeSection(m[2])
+			skipping = false
+			kept = append(kept, line)
+			continue
+		}
+
+		// Entry lines name companies, titles, schools, degrees and dates; bullet points are
+		// descriptions, whose numbers are not checked
+		if section != "" && isEntryLine(line) {
+			skipping = false
+			for _, date := range facts.unknownDates(line) {
+				findings = append(findings, models.VerificationFinding{Kind: models.FindingDate, Value: date, Location: location, Action: action})
+				strip = true
+			}
+			for _, part := range entryParts(line) {
+				if isDatePart(part) || facts.isLocation(part) {
+					continue
+				}
+				known := facts.knownEntity(part, facts.companies, facts.titles)
+				if section == "education" {
+					known = facts.knownEntity(part, facts.schools, facts.degrees)
+				}
+				if !known {
+					findings = append(findings, models.VerificationFinding{Kind: models.FindingEntry, Value: part, Location: location, Action: action})
+					strip = true
+				}
+			}
+		}
+
+		// A stripped entry takes its description with it; labeled lines such as
+		// "**Technologies:** Go" are never removed
+		if mode == config.VerifyModeStrip && !isLabelLine(line) && (strip || skipping && strings.TrimSpace(line) != "") {
+			skipping = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.Join(kept, "\n"), findings
+}
+
+// resumeSection classifies a section heading: "experience", "education" or empty for
+// sections whose entries are not checked
+func resumeSection(heading string) string {
+	heading = strings.ToLower(heading)
+	switch {
+	case strings.Contains(heading, "experience") || strings.Contains(heading, "employment") || strings.Contains(heading, "work history"):
+		return "experience"
+	case strings.Contains(heading, "education"):
+		return "education"
+	}
+	return ""
+}
+
+// isEntryLine reports whether a line introduces an entry: a heading of level 3 or below, or
+// a bold line naming a title and a company like "**Engineer** at Acme | 2020 - Present".
+// Other lines, including labeled ones like "**Technologies:** Go", are descriptions.
+func isEntryLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || bulletPattern.MatchString(line) || isLabelLine(trimmed) {
+		return false
+	}
+	if m := headingPattern.FindStringSubmatch(trimmed); m != nil {
+		return len(m[1]) >= 3
+	}
+	return boldEntryPattern.MatchString(trimmed)
+}
+
+// isLabelLine reports whether a line starts with a bold label such as "**Technologies:**"
+func isLabelLine(line string) bool {
+	return labelLinePattern.MatchString(strings.TrimSpace(line))
+}
+
+// entryParts splits an entry line such as "**Engineer** | Acme Corp | Jan 2020 - Present" into its values
+func entryParts(line string) []string {
+	line = strings.TrimLeft(strings.TrimSpace(line), "#")
+	line = strings.NewReplacer("**", " | ", "__", " | ", "*", " ", "_", " ").Replace(line)
+
+	var parts []string
+	for _, part := range entrySeparatorPattern.Split(line, -1) {
+		part = strings.Trim(strings.TrimSpace(part), ":;.")
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// isDatePart reports whether an entry part is a date or a date range
+func isDatePart(part string) bool {
+	if presentPattern.MatchString(strings.TrimSpace(part)) {
+		return true
+	}
+	rest := monthYearPattern.ReplaceAllString(part, "")
+	rest = yearPattern.ReplaceAllString(rest, "")
+	rest = strings.Trim(rest, " -–—to")
+	return rest == "" || presentPattern.MatchString(strings.TrimSpace(rest))
+}
+
+// verifyStructured checks a structured resume against the profile. Personal details are
+// always corrected; in strip mode entries with companies, titles, schools, degrees or dates
+// that are not in the profile are removed.
+func verifyStructured(content *models.ResumeContent, user *models.User, mode string) models.VerificationFindings {
+	facts := newProfileFacts(user)
+	findings := models.VerificationFindings{}
+	action := models.FindingFlagged
+	if mode == config.VerifyModeStrip {
+		action = models.FindingStripped
+	}
+
+	personal := []struct{ kind, value, expected string }{
+		{models.FindingName, content.PersonalInfo.Name, user.FullName},
+		{models.FindingEmail, content.PersonalInfo.Email, user.Email},
+		{models.FindingPhone, content.PersonalInfo.Phone, user.Phone},
+	}
+	for _, p := range personal {
+		if p.value != "" && p.value != p.expected {
+			findings = append(findings, models.VerificationFinding{Kind: p.kind, Value: p.value, Expected: p.expected, Location: "personalInfo." + p.kind, Action: models.FindingCorrected})
+		}
+	}
+
+	experience := content.Experience[:0]
+	for i, exp := range content.Experience {
+		path := fmt.Sprintf("experience[%d]", i)
+		var entryFindings models.VerificationFindings
+		if !facts.knownEntity(exp.Company, facts.companies) {
+			entryFindings = append(entryFindings, models.VerificationFinding{Kind: models.FindingCompany, Value: exp.Company, Location: path + ".company", Action: action})
+		}
+		if !facts.knownEntity(exp.Title, facts.titles) {
+			entryFindings = append(entryFindings, models.VerificationFinding{Kind: models.FindingTitle, Value: exp.Title, Location: path + ".title", Action: action})
+		}
+		entryFindings = append(entryFindings, facts.periodFindings(path, &exp.StartDate, exp.EndDate, action)...)
+
+		findings = append(findings, entryFindings...)
+		if len(entryFindings) == 0 || mode != config.VerifyModeStrip {
+			experience = append(experience, exp)
+		}
+	}
+	content.Experience = experience
+
+	education := content.Education[:0]
+	for i, edu := range content.Education {
+		path := fmt.Sprintf("education[%d]", i)
+		var entryFindings models.VerificationFindings
+		if !facts.knownEntity(edu.School, facts.schools) {
+			entryFindings = append(entryFindings, models.VerificationFinding{Kind: models.FindingSchool, Value: edu.School, Location: path + ".school", Action: action})
+		}
+		if !facts.knownEntity(edu.Degree, facts.degrees) {
+			entryFindings = append(entryFindings, models.VerificationFinding{Kind: models.FindingDegree, Value: edu.Degree, Location: path + ".degree", Action: action})
+		}
+		entryFindings = append(entryFindings, facts.periodFindings(path, &edu.StartDate, edu.EndDate, action)...)
+
+		findings = append(findings, entryFindings...)
+		if len(entryFindings) == 0 || mode != config.VerifyModeStrip {
+			education = append(education, edu)
+		}
+	}
+	content.Education = education
+
+	return findings
+}
+
+// periodFindings checks the start and end month of an entry
+func (f *profileFacts) periodFindings(path string, start, end *time.Time, action string) models.VerificationFindings {
+	var findings models.VerificationFindings
+	dates := []struct {
+		field string
+		date  *time.Time
+	}{{"startDate", start}, {"endDate", end}}
+	for _, d := range dates {
+		field, date := d.field, d.date
+		if date == nil || date.IsZero() {
+			continue
+		}
+		if !f.monthYears[date.Format("2006-01")] {
+			findings = append(findings, models.VerificationFinding{Kind: models.FindingDate, Value: date.Format("2006-01"), Location: path + "." + field, Action: action})
+		}
+	}
+	return findings
+}
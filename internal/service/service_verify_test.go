@@ -0,0 +1,70 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nikolai/ai-resume-builder/backend/internal/config"
+	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"github.com/nikolai/ai-resume-builder/backend/internal/repotest"
+)
+
+func TestVerifyMarkdownEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+		want  []string // Parts reported as entries that match no profile record
+	}{
+		{"profile entry", "### Senior Backend Engineer | Acme Corp | Berlin | Mar 2021 - Present", nil},
+		{"shortened company", "### Senior Backend Engineer at Acme | Mar 2021 - Present", nil},
+		{"company with legal form", "### Senior Backend Engineer, Acme Corp GmbH", nil},
+		{"company with profile city", "### Senior Backend Engineer | Acme Corp Berlin", nil},
+		{"unknown company in profile city", "### Senior Backend Engineer | Google Berlin | Mar 2021 - Present", []string{"Google Berlin"}},
+		{"raised title", "### Staff Backend Engineer | Acme Corp | Mar 2021 - Present", []string{"Staff Backend Engineer"}},
+		{"raised title at unknown company", "### Staff Backend Engineer | Google Berlin | Mar 2021 - Present", []string{"Staff Backend Engineer", "Google Berlin"}},
+		{"company inside a word", "### Senior Backend Engineer | Acmeville", []string{"Acmeville"}},
+		{"unknown city", "### Senior Backend Engineer | Acme Corp | Munich", []string{"Munich"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "# Jane Doe\n\n## Experience\n\n" + tt.entry + "\n\n- Led the billing migration\n"
+			_, findings := verifyMarkdown(content, repotest.User(), config.VerifyModeFlag)
+
+			var got []string
+			for _, finding := range findings {
+				if finding.Kind == models.FindingEntry {
+					got = append(got, finding.Value)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got entry findings %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnownEntity(t *testing.T) {
+	facts := newProfileFacts(repotest.User())
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Acme Corp", true},
+		{"acme corp.", true},
+		{"Acme", true},
+		{"Acme Corp Inc", true},
+		{"Berlin Acme Corp", true},
+		{"Google Berlin", false},
+		{"Acmeville", false},
+		{"Acme Corp Cloud", false},
+		{"Senior Backend Engineer", true},
+		{"Backend Engineer", true},
+		{"Staff Backend Engineer", false},
+		{"Co", false},
+	}
+	for _, tt := range tests {
+		if got := facts.knownEntity(tt.text, facts.companies, facts.titles); got != tt.want {
+			t.Errorf("knownEntity(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -3,9 +3,12 @@ package utils
 import (
 	"bytes"
 	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/jung-kurt/gofpdf"
 	"github.com/nikolai/ai-resume-builder/backend/internal/models"
+	"golang.org/x/text/unicode/norm"
 )
 
 func GeneratePDF(resume models.ResumeContent) ([]byte, error) {
@@ -13,62 +16,70 @@ func GeneratePDF(resume models.ResumeContent) ([]byte, error) {
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
 
+	// Core fonts are cp1252 encoded, so translate UTF-8 text (bullets, accents) before writing
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	cell := func(h float64, text string) {
+		pdf.Cell(0, h, tr(text))
+	}
+	multiCell := func(h float64, text string) {
+		pdf.MultiCell(0, h, tr(text), "", "", false)
+	}
+
 	// Personal Information
-	pdf.Cell(0, 10, resume.PersonalInfo.Name)
+	cell(10, resume.PersonalInfo.Name)
 	pdf.Ln(8)
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 5, resume.PersonalInfo.Email)
+	cell(5, resume.PersonalInfo.Email)
 	pdf.Ln(5)
-	pdf.Cell(0, 5, resume.PersonalInfo.Phone)
+	cell(5, resume.PersonalInfo.Phone)
 	pdf.Ln(10)
 
 	// Summary
 	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 10, "Professional Summary")
+	cell(10, "Professional Summary")
 	pdf.Ln(8)
 	pdf.SetFont("Arial", "", 10)
-	pdf.MultiCell(0, 5, resume.Summary, "", "", false)
+	multiCell(5, resume.Summary)
 	pdf.Ln(5)
 
 	// Experience
 	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 10, "Experience")
+	cell(10, "Experience")
 	pdf.Ln(8)
 	for _, exp := range resume.Experience {
 		pdf.SetFont("Arial", "B", 10)
-		pdf.Cell(0, 5, fmt.Sprintf("%s - %s", exp.Company, exp.Title))
+		cell(5, fmt.Sprintf("%s - %s", exp.Company, exp.Title))
 		pdf.Ln(5)
 		pdf.SetFont("Arial", "", 10)
-		pdf.Cell(0, 5, fmt.Sprintf("%s - %s", exp.StartDate.Format("Jan 2006"), getEndDate(exp)))
+		cell(5, fmt.Sprintf("%s - %s", exp.StartDate.Format("Jan 2006"), getEndDate(exp)))
 		pdf.Ln(5)
 		for _, desc := range exp.Description {
-			pdf.Cell(0, 5, "• "+desc)
-			pdf.Ln(5)
+			multiCell(5, "• "+desc)
 		}
 		pdf.Ln(3)
 	}
 
 	// Education
 	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 10, "Education")
+	cell(10, "Education")
 	pdf.Ln(8)
 	for _, edu := range resume.Education {
 		pdf.SetFont("Arial", "B", 10)
-		pdf.Cell(0, 5, edu.School)
+		cell(5, edu.School)
 		pdf.Ln(5)
 		pdf.SetFont("Arial", "", 10)
-		pdf.Cell(0, 5, fmt.Sprintf("%s in %s", edu.Degree, edu.Field))
+		cell(5, fmt.Sprintf("%s in %s", edu.Degree, edu.Field))
 		pdf.Ln(5)
-		pdf.Cell(0, 5, fmt.Sprintf("%s - %s", edu.StartDate.Format("Jan 2006"), getEducationEndDate(edu)))
+		cell(5, fmt.Sprintf("%s - %s", edu.StartDate.Format("Jan 2006"), getEducationEndDate(edu)))
 		pdf.Ln(8)
 	}
 
 	// Skills
 	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 10, "Skills")
+	cell(10, "Skills")
 	pdf.Ln(8)
 	pdf.SetFont("Arial", "", 10)
-	pdf.MultiCell(0, 5, formatSkills(resume.Skills), "", "", false)
+	multiCell(5, formatSkills(resume.Skills))
 
 	// Generate PDF bytes
 	var buf bytes.Buffer
@@ -80,6 +91,30 @@ func GeneratePDF(resume models.ResumeContent) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// PDFFilename builds a download file name such as "jane-doe-resume.pdf" from a person's name
+func PDFFilename(name string) string {
+	var b strings.Builder
+	lastDash := true
+	// Decompose accented letters so "José" becomes "jose" once the marks are dropped
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteByte('-')
+			lastDash = true
+		}
+	}
+	base := strings.TrimSuffix(b.String(), "-")
+	if base == "" {
+		return "resume.pdf"
+	}
+	return base + "-resume.pdf"
+}
+
 func getEndDate(exp models.Experience) string {
 	if exp.Current {
 		return "Present"